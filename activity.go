@@ -0,0 +1,75 @@
+package vk
+
+import (
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	ActivityTyping       = "typing"
+	ActivityAudioMessage = "audiomessage"
+
+	// VK shows the activity for about 10 seconds
+	activityRefresh = time.Second * 5
+)
+
+// MsgsSetActivity implements method https://vk.com/dev/messages.setActivity.
+// typ is ActivityTyping or ActivityAudioMessage.
+func (s *Session) MsgsSetActivity(peerId int, typ string) error {
+	vals := make(url.Values)
+	vals.Set("peer_id", strconv.Itoa(peerId))
+	vals.Set("type", typ)
+	var b Bool
+	return s.CallAPI("messages.setActivity", vals, &b)
+}
+
+// MsgsMarkAsReadByPeer implements method https://vk.com/dev/messages.markAsRead
+// for the messages of peerId up to startMsgId. Zero startMsgId marks the whole
+// conversation as read.
+func (s *Session) MsgsMarkAsReadByPeer(peerId, startMsgId int) error {
+	vals := make(url.Values)
+	vals.Set("peer_id", strconv.Itoa(peerId))
+	if startMsgId != 0 {
+		vals.Set("start_message_id", strconv.Itoa(startMsgId))
+	}
+	var b Bool
+	return s.CallAPI("messages.markAsRead", vals, &b)
+}
+
+// KeepTyping shows typing indicator in conversation peerId till the returned
+// stop function is called. stop waits for messages.setActivity in flight,
+// so the indicator is not set again after the reply is sent. Errors of
+// messages.setActivity are ignored as the indicator is only cosmetic.
+func KeepTyping(s *Session, peerId int) (stop func()) {
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		t := time.NewTicker(activityRefresh)
+		defer t.Stop()
+		for {
+			s.MsgsSetActivity(peerId, ActivityTyping)
+			select {
+			case <-done:
+				return
+			case <-t.C:
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+		<-exited
+	}
+}
+
+// WithTyping keeps typing indicator in conversation peerId while f runs, for
+// example while a callback handler prepares the answer, and returns the
+// error of f.
+func WithTyping(s *Session, peerId int, f func() error) error {
+	stop := KeepTyping(s, peerId)
+	defer stop()
+	return f()
+}
@@ -18,6 +18,7 @@ const (
 	AT_Audio       = "audio"
 	AT_Doc         = "doc"
 	AT_Graffiti    = "graffiti"
+	AT_AudioMsg    = "audio_message"
 	AT_Url         = "url"
 	AT_Link        = "link"
 	AT_Note        = "note"
@@ -36,6 +37,8 @@ type (
 		A    *Audio          `json:"audio"`
 		D    *Doc            `json:"doc"`
 		G    json.RawMessage `json:"geo"`
+		AM   *AudioMsg       `json:"audio_message"`
+		Gr   *Graffiti       `json:"graffiti"`
 	}
 
 	ReceiveContent string
@@ -53,7 +56,12 @@ type (
 			Votes int     `json:"votes"`
 			Rate  float32 `json:"rate"`
 		} `json:"answers"`
-		Anonymous int `json:"anonymous"`
+		Anonymous Bool  `json:"anonymous"`
+		Multiple  bool  `json:"multiple"`
+		EndDate   int64 `json:"end_date"`
+		Closed    bool  `json:"closed"`
+		CanVote   bool  `json:"can_vote"`
+		AnswerIds []int `json:"answer_ids"`
 	}
 
 	Photo struct {
@@ -119,6 +127,29 @@ type (
 		Type      int    `json:"type"`
 		AccessKey string `json:"access_key"`
 	}
+
+	// AudioMsg is voice message. Waveform is the volume levels (0-31) for
+	// drawing its preview.
+	AudioMsg struct {
+		ReceiveContent
+		Id        int    `json:"id"`
+		OwnerId   int    `json:"owner_id"`
+		Duration  int    `json:"duration"` // in sec
+		Waveform  []int  `json:"waveform"`
+		LinkOgg   string `json:"link_ogg"`
+		LinkMp3   string `json:"link_mp3"`
+		AccessKey string `json:"access_key"`
+	}
+
+	Graffiti struct {
+		ReceiveContent
+		Id        int    `json:"id"`
+		OwnerId   int    `json:"owner_id"`
+		Url       string `json:"url"`
+		Width     int    `json:"width"`
+		Height    int    `json:"height"`
+		AccessKey string `json:"access_key"`
+	}
 )
 
 // Another approach to get the Attachment object from raw bytes string.
@@ -162,6 +193,8 @@ func (a *Attachment) UnmarshalJSON(b []byte) error {
 		A    *Audio          `json:"audio"`
 		D    *Doc            `json:"doc"`
 		G    json.RawMessage `json:"geo"`
+		AM   *AudioMsg       `json:"audio_message"`
+		Gr   *Graffiti       `json:"graffiti"`
 	}
 	if err := json.Unmarshal(b, &v); err != nil {
 		return err
@@ -174,6 +207,8 @@ func (a *Attachment) UnmarshalJSON(b []byte) error {
 	a.A = v.A
 	a.D = v.D
 	a.G = v.G
+	a.AM = v.AM
+	a.Gr = v.Gr
 	return nil
 }
 
@@ -301,3 +336,55 @@ func (a *Attachment) Poll() (*Poll, error) {
 	}
 	return a.Pl, nil
 }
+
+// AudioMsg content is the mp3 link of the voice message.
+func (a *Attachment) AudioMsg() (*AudioMsg, error) {
+	if a.Type != AT_AudioMsg {
+		return nil, errors.New("vk: not audio message json")
+	}
+	v := a.AM
+	if v.LinkMp3 != "" {
+		v.ReceiveContent = ReceiveContent(v.LinkMp3)
+	} else if v.LinkOgg != "" {
+		v.ReceiveContent = ReceiveContent(v.LinkOgg)
+	}
+	return v, nil
+}
+
+func (a *Attachment) Graffiti() (*Graffiti, error) {
+	if a.Type != AT_Graffiti {
+		return nil, errors.New("vk: not graffiti json")
+	}
+	v := a.Gr
+	if v.Url != "" {
+		v.ReceiveContent = ReceiveContent(v.Url)
+	}
+	return v, nil
+}
+
+func attachString(typ string, owner, id int, key string) string {
+	s := fmt.Sprint(typ, owner, "_", id)
+	if key != "" {
+		s = fmt.Sprint(s, "_", key)
+	}
+	return s
+}
+
+// String returns attachment string such as "photo100_200" that can be used
+// to attach the same media to post or message. It is empty for the types
+// that can not be attached again.
+func (a *Attachment) String() string {
+	switch {
+	case a.Type == AT_Photo && a.P != nil:
+		return attachString(a.Type, a.P.OwnerId, a.P.Id, a.P.AccessKey)
+	case a.Type == AT_Video && a.V != nil:
+		return attachString(a.Type, a.V.OwnerId, a.V.Id, a.V.AccessKey)
+	case a.Type == AT_Audio && a.A != nil:
+		return attachString(a.Type, a.A.Owner, a.A.Id, "")
+	case a.Type == AT_Doc && a.D != nil:
+		return attachString(a.Type, a.D.OwnerId, a.D.Id, a.D.AccessKey)
+	case a.Type == AT_Poll && a.Pl != nil:
+		return a.Pl.String()
+	}
+	return ""
+}
@@ -0,0 +1,329 @@
+package vk
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+)
+
+const (
+	// board.getTopics order
+	TopicOrderUpdatedDesc = 1
+	TopicOrderCreatedDesc = 2
+	TopicOrderUpdatedAsc  = -1
+	TopicOrderCreatedAsc  = -2
+
+	maxBoardCount = 100
+)
+
+type (
+	// Topics is returned by board.getTopics
+	Topics struct {
+		Count        int      `json:"count"`
+		Items        []*Topic `json:"items"`
+		DefaultOrder int      `json:"default_order"`
+		CanAddTopics Bool     `json:"can_add_topics"`
+		Profiles     []User   `json:"profiles"`
+	}
+
+	// TopicsParams is the parameters of https://vk.com/dev/board.getTopics.
+	// Zero Count uses VK default (40).
+	TopicsParams struct {
+		GroupId  int
+		TopicIds []int
+		Order    int
+		Offset   int
+		Count    int
+		Extended bool
+	}
+
+	// TopicComments is returned by board.getComments
+	TopicComments struct {
+		Count int             `json:"count"`
+		Items []*TopicComment `json:"items"`
+		// RealOffset is the offset of the first item when StartCommentId of
+		// TopicCommentsParams is set.
+		RealOffset int     `json:"real_offset"`
+		Poll       *Poll   `json:"poll"`
+		Profiles   []User  `json:"profiles"`
+		Groups     []Group `json:"groups"`
+	}
+
+	// TopicCommentsParams is the parameters of
+	// https://vk.com/dev/board.getComments. Zero Count uses VK default (20).
+	TopicCommentsParams struct {
+		GroupId        int
+		TopicId        int
+		NeedLikes      bool
+		StartCommentId int
+		Offset         int
+		Count          int
+		Sort           string // CommentSortAsc or CommentSortDesc
+		Extended       bool
+	}
+
+	// TopicCommentParams is the parameters of
+	// https://vk.com/dev/board.createComment
+	TopicCommentParams struct {
+		GroupId     int
+		TopicId     int
+		Message     string
+		Attachments string
+		FromGroup   bool
+		StickerId   int
+		Guid        string
+	}
+)
+
+// BoardGetTopics implements method https://vk.com/dev/board.getTopics
+func (s *Session) BoardGetTopics(p *TopicsParams) (*Topics, error) {
+	if p.Count > maxBoardCount {
+		return nil, errors.New("vk: topics count is limited to 100")
+	}
+	vals := make(url.Values)
+	vals.Set("group_id", strconv.Itoa(p.GroupId))
+	if len(p.TopicIds) > 0 {
+		vals.Set("topic_ids", IdList(p.TopicIds).String())
+	}
+	if p.Order != 0 {
+		vals.Set("order", strconv.Itoa(p.Order))
+	}
+	if p.Offset > 0 {
+		vals.Set("offset", strconv.Itoa(p.Offset))
+	}
+	if p.Count > 0 {
+		vals.Set("count", strconv.Itoa(p.Count))
+	}
+	boolVal(vals, "extended", p.Extended)
+	var t Topics
+	if err := s.CallAPI("board.getTopics", vals, &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// BoardGetComments implements method https://vk.com/dev/board.getComments
+func (s *Session) BoardGetComments(p *TopicCommentsParams) (*TopicComments, error) {
+	if p.Count > maxBoardCount {
+		return nil, errors.New("vk: comments count is limited to 100")
+	}
+	vals := make(url.Values)
+	vals.Set("group_id", strconv.Itoa(p.GroupId))
+	vals.Set("topic_id", strconv.Itoa(p.TopicId))
+	boolVal(vals, "need_likes", p.NeedLikes)
+	if p.StartCommentId != 0 {
+		vals.Set("start_comment_id", strconv.Itoa(p.StartCommentId))
+	}
+	if p.Offset > 0 {
+		vals.Set("offset", strconv.Itoa(p.Offset))
+	}
+	if p.Count > 0 {
+		vals.Set("count", strconv.Itoa(p.Count))
+	}
+	if p.Sort != "" {
+		vals.Set("sort", p.Sort)
+	}
+	boolVal(vals, "extended", p.Extended)
+	var c TopicComments
+	if err := s.CallAPI("board.getComments", vals, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// BoardAddTopic implements method https://vk.com/dev/board.addTopic and
+// returns the new topic ID. text is the first comment of the topic.
+func (s *Session) BoardAddTopic(gid int, title, text, attachments string,
+	fromGroup bool) (int, error) {
+	vals := make(url.Values)
+	vals.Set("group_id", strconv.Itoa(gid))
+	vals.Set("title", title)
+	if text != "" {
+		vals.Set("text", text)
+	}
+	if attachments != "" {
+		vals.Set("attachments", attachments)
+	}
+	boolVal(vals, "from_group", fromGroup)
+	var id int
+	if err := s.CallAPI("board.addTopic", vals, &id); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// BoardCreateComment implements method https://vk.com/dev/board.createComment
+// and returns the new comment ID.
+func (s *Session) BoardCreateComment(p *TopicCommentParams) (int, error) {
+	if p.Message == "" && p.Attachments == "" && p.StickerId == 0 {
+		return 0, errors.New("vk: empty comment")
+	}
+	vals := make(url.Values)
+	vals.Set("group_id", strconv.Itoa(p.GroupId))
+	vals.Set("topic_id", strconv.Itoa(p.TopicId))
+	if p.Message != "" {
+		vals.Set("message", p.Message)
+	}
+	if p.Attachments != "" {
+		vals.Set("attachments", p.Attachments)
+	}
+	boolVal(vals, "from_group", p.FromGroup)
+	if p.StickerId != 0 {
+		vals.Set("sticker_id", strconv.Itoa(p.StickerId))
+	}
+	if p.Guid != "" {
+		vals.Set("guid", p.Guid)
+	}
+	var id int
+	if err := s.CallAPI("board.createComment", vals, &id); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// BoardEditComment implements method https://vk.com/dev/board.editComment
+func (s *Session) BoardEditComment(gid, topicId, id int, m, a string) error {
+	vals := make(url.Values)
+	vals.Set("group_id", strconv.Itoa(gid))
+	vals.Set("topic_id", strconv.Itoa(topicId))
+	vals.Set("comment_id", strconv.Itoa(id))
+	vals.Set("message", m)
+	if a != "" {
+		vals.Set("attachments", a)
+	}
+	var b Bool
+	return s.CallAPI("board.editComment", vals, &b)
+}
+
+// BoardDeleteComment implements method https://vk.com/dev/board.deleteComment
+func (s *Session) BoardDeleteComment(gid, topicId, id int) error {
+	vals := make(url.Values)
+	vals.Set("group_id", strconv.Itoa(gid))
+	vals.Set("topic_id", strconv.Itoa(topicId))
+	vals.Set("comment_id", strconv.Itoa(id))
+	var b Bool
+	return s.CallAPI("board.deleteComment", vals, &b)
+}
+
+func boardTopicAct(s *Session, act string, gid, topicId int) error {
+	vals := make(url.Values)
+	vals.Set("group_id", strconv.Itoa(gid))
+	vals.Set("topic_id", strconv.Itoa(topicId))
+	var b Bool
+	return s.CallAPI(act, vals, &b)
+}
+
+// BoardCloseTopic implements method https://vk.com/dev/board.closeTopic
+func (s *Session) BoardCloseTopic(gid, topicId int) error {
+	return boardTopicAct(s, "board.closeTopic", gid, topicId)
+}
+
+// BoardOpenTopic implements method https://vk.com/dev/board.openTopic
+func (s *Session) BoardOpenTopic(gid, topicId int) error {
+	return boardTopicAct(s, "board.openTopic", gid, topicId)
+}
+
+// BoardFixTopic implements method https://vk.com/dev/board.fixTopic
+func (s *Session) BoardFixTopic(gid, topicId int) error {
+	return boardTopicAct(s, "board.fixTopic", gid, topicId)
+}
+
+// BoardUnfixTopic implements method https://vk.com/dev/board.unfixTopic
+func (s *Session) BoardUnfixTopic(gid, topicId int) error {
+	return boardTopicAct(s, "board.unfixTopic", gid, topicId)
+}
+
+// BoardDeleteTopic implements method https://vk.com/dev/board.deleteTopic
+func (s *Session) BoardDeleteTopic(gid, topicId int) error {
+	return boardTopicAct(s, "board.deleteTopic", gid, topicId)
+}
+
+type TopicLooper interface {
+	More() (*Topic, error)
+	Size() int
+}
+
+type topicLoop struct {
+	pager
+	sess  *Session
+	p     TopicsParams
+	items []*Topic
+}
+
+// NewTopicsLoop creates TopicLooper over all the topics of p starting at
+// p.Offset. Size is known after the first More.
+func NewTopicsLoop(s *Session, p *TopicsParams) TopicLooper {
+	l := &topicLoop{sess: s, p: *p}
+	l.p.Count = maxBoardCount
+	l.size, l.offset = maxBoardCount, p.Offset
+	return l
+}
+
+func (l *topicLoop) page(offset int) (int, int, error) {
+	l.p.Offset = offset
+	r, err := l.sess.BoardGetTopics(&l.p)
+	if err != nil {
+		return 0, 0, err
+	}
+	l.items = r.Items
+	return len(r.Items), r.Count, nil
+}
+
+func (l *topicLoop) More() (*Topic, error) {
+	for len(l.items) == 0 {
+		if err := l.next(l.page); err != nil {
+			return nil, err
+		}
+	}
+	t := l.items[0]
+	l.items = l.items[1:]
+	return t, nil
+}
+
+type TopicCommentLooper interface {
+	More() (*TopicComment, error)
+	Size() int
+}
+
+type topicCommentLoop struct {
+	pager
+	sess  *Session
+	p     TopicCommentsParams
+	items []*TopicComment
+}
+
+// NewTopicCommentsLoop creates TopicCommentLooper over all the comments of
+// p starting at p.Offset, or at the page around p.StartCommentId when it is
+// set. Size is known after the first More.
+func NewTopicCommentsLoop(s *Session, p *TopicCommentsParams) TopicCommentLooper {
+	l := &topicCommentLoop{sess: s, p: *p}
+	l.p.Count = maxBoardCount
+	l.size, l.offset = maxBoardCount, p.Offset
+	return l
+}
+
+func (l *topicCommentLoop) page(offset int) (int, int, error) {
+	l.p.Offset = offset
+	r, err := l.sess.BoardGetComments(&l.p)
+	if err != nil {
+		return 0, 0, err
+	}
+	l.items = r.Items
+	if l.p.StartCommentId != 0 {
+		// the page is around the start comment, continue after it
+		l.offset = r.RealOffset
+		l.p.StartCommentId = 0
+	}
+	return len(r.Items), r.Count, nil
+}
+
+func (l *topicCommentLoop) More() (*TopicComment, error) {
+	for len(l.items) == 0 {
+		if err := l.next(l.page); err != nil {
+			return nil, err
+		}
+	}
+	c := l.items[0]
+	l.items = l.items[1:]
+	return c, nil
+}
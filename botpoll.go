@@ -0,0 +1,186 @@
+package vk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// long poll failed codes
+	LPFailedHistory = 1 // ts outdated, continue with the returned ts
+	LPFailedKey     = 2 // key expired, get new key with the same ts
+	LPFailedInfo    = 3 // information lost, get new key and ts
+	LPFailedVersion = 4 // invalid version (user long poll only)
+
+	defaultLPWait = 25
+	lpRetryDelay  = time.Second * 3
+)
+
+// LPTs is long poll timestamp. VK send it as number or string depending on
+// the kind of long poll and version.
+type LPTs string
+
+func (t *LPTs) UnmarshalJSON(b []byte) error {
+	*t = LPTs(strings.Trim(string(b), `"`))
+	return nil
+}
+
+func (t LPTs) String() string {
+	return string(t)
+}
+
+type (
+	// BotsLongPollServer is returned by groups.getLongPollServer
+	BotsLongPollServer struct {
+		Key    string `json:"key"`
+		Server string `json:"server"`
+		Ts     LPTs   `json:"ts"`
+	}
+
+	botsLPResponse struct {
+		Ts      LPTs              `json:"ts"`
+		Updates []*ReceivedResult `json:"updates"`
+		Failed  int               `json:"failed"`
+	}
+
+	// BotsLongPoll receives community events through bots long poll
+	// (https://vk.com/dev/bots_longpoll) when callback API server can not be
+	// exposed. Session must use community token.
+	BotsLongPoll struct {
+		sess   *Session
+		gid    int
+		server *BotsLongPollServer
+		// Wait is the long poll waiting time in seconds. Max is 90.
+		Wait int
+		// Client is used for the long poll requests. Its timeout must be
+		// longer than Wait.
+		Client *http.Client
+	}
+)
+
+// GroupsGetLongPollServer implements method
+// https://vk.com/dev/groups.getLongPollServer
+func (s *Session) GroupsGetLongPollServer(gid int) (*BotsLongPollServer, error) {
+	v := url.Values{}
+	v.Set("group_id", strconv.Itoa(gid))
+	var r BotsLongPollServer
+	if err := s.CallAPI("groups.getLongPollServer", v, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// NewBotsLongPoll creates bots long poll client of community gid.
+func NewBotsLongPoll(s *Session, gid int) *BotsLongPoll {
+	return &BotsLongPoll{
+		sess:   s,
+		gid:    gid,
+		Wait:   defaultLPWait,
+		Client: http.DefaultClient,
+	}
+}
+
+// refresh gets new key and server. Current ts is kept if keepTs true.
+func (lp *BotsLongPoll) refresh(keepTs bool) error {
+	srv, err := lp.sess.GroupsGetLongPollServer(lp.gid)
+	if err != nil {
+		return err
+	}
+	if keepTs && lp.server != nil {
+		srv.Ts = lp.server.Ts
+	}
+	lp.server = srv
+	return nil
+}
+
+// Poll does one long poll request and returns the received events. It may
+// return empty result when no event is received within Wait or when the
+// connection info had to be refreshed.
+func (lp *BotsLongPoll) Poll(ctx context.Context) ([]*ReceivedResult, error) {
+	rs, ts, err := lp.poll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	lp.server.Ts = ts
+	return rs, nil
+}
+
+// poll is Poll without advancing ts. It returns the ts to continue with
+// after the events.
+func (lp *BotsLongPoll) poll(ctx context.Context) ([]*ReceivedResult, LPTs, error) {
+	if lp.server == nil {
+		if err := lp.refresh(false); err != nil {
+			return nil, "", err
+		}
+	}
+	q := url.Values{}
+	q.Set("act", "a_check")
+	q.Set("key", lp.server.Key)
+	q.Set("ts", lp.server.Ts.String())
+	q.Set("wait", strconv.Itoa(lp.Wait))
+	req, err := http.NewRequest("GET", fmt.Sprint(lp.server.Server, "?", q.Encode()), nil)
+	if err != nil {
+		return nil, "", err
+	}
+	resp, err := lp.Client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	var r botsLPResponse
+	if err = json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return nil, "", err
+	}
+	switch r.Failed {
+	case 0:
+	case LPFailedHistory:
+		return nil, r.Ts, nil
+	case LPFailedKey:
+		err = lp.refresh(true)
+	default:
+		err = lp.refresh(false)
+	}
+	if err != nil {
+		return nil, "", err
+	} else if r.Failed != 0 {
+		return nil, lp.server.Ts, nil
+	}
+	return r.Updates, r.Ts, nil
+}
+
+// Run polls till ctx is done and passes every received event to h. A
+// Dispatcher.Dispatch can be used as h. Error from h stops Run and is
+// returned. Ts is only advanced after h handled the whole batch, so like
+// callback API resending the batch is received again by the next Poll or
+// Run. Failed request is retried after short delay.
+func (lp *BotsLongPoll) Run(ctx context.Context, h Handler) error {
+	for {
+		rs, ts, err := lp.poll(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			if Debug {
+				fmt.Printf("vk bots long poll: %v\n", err)
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(lpRetryDelay):
+			}
+			continue
+		}
+		for _, rr := range rs {
+			if err = h(rr); err != nil {
+				return err
+			}
+		}
+		lp.server.Ts = ts
+	}
+}
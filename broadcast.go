@@ -0,0 +1,210 @@
+package vk
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"strconv"
+)
+
+// BroadcastResult is the sending result of one recipient. ErrCode is zero
+// when the message is sent, ErrMsgsCantSend when the user does not allow
+// messages from the community.
+type BroadcastResult struct {
+	UserId    int    `json:"user_id"`
+	MessageId int    `json:"message_id,omitempty"`
+	ErrCode   int    `json:"error_code,omitempty"`
+	ErrMsg    string `json:"error_msg,omitempty"`
+}
+
+// Broadcaster sends same message to many users of a community through
+// messages.send user_ids batches. Results are appended to a state file so
+// interrupted broadcast is resumed without resending. Calls go through
+// CallAPI and obey its rate limit.
+type Broadcaster struct {
+	sess *Session
+	gid  int
+	p    MsgSendParams
+	path string
+	id   int
+	// CheckAllowed checks messages.isMessagesFromGroupAllowed of every
+	// recipient before sending. It costs one call per recipient.
+	CheckAllowed bool
+}
+
+// NewBroadcaster creates Broadcaster of community gid sending p (recipient
+// fields are ignored) and keeping the results in file statePath. Each new
+// state file is a new broadcast, so use another one (or remove it) to send
+// the same message again.
+func NewBroadcaster(s *Session, gid int, p *MsgSendParams, statePath string) *Broadcaster {
+	b := &Broadcaster{sess: s, gid: gid, p: *p, path: statePath}
+	b.p.PeerId, b.p.UserId, b.p.ChatId, b.p.Domain = 0, 0, 0, ""
+	return b
+}
+
+// broadcastLine is a line of the state file, either the broadcast ID line
+// written first or a result.
+type broadcastLine struct {
+	BroadcastResult
+	BroadcastId int `json:"broadcast_id,omitempty"`
+}
+
+// load reads the results and the broadcast ID of the state file. Zero ID
+// with no result means new broadcast.
+func (b *Broadcaster) load() (map[int]*BroadcastResult, int, error) {
+	done := make(map[int]*BroadcastResult)
+	f, err := os.Open(b.path)
+	if os.IsNotExist(err) {
+		return done, 0, nil
+	} else if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+	var id int
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		v := new(broadcastLine)
+		if json.Unmarshal(sc.Bytes(), v) != nil {
+			// incomplete line of interrupted write
+			continue
+		}
+		if v.BroadcastId != 0 {
+			id = v.BroadcastId
+		} else if v.UserId != 0 {
+			r := v.BroadcastResult
+			done[r.UserId] = &r
+		}
+	}
+	return done, id, sc.Err()
+}
+
+// batchRandomId derives random_id from the broadcast ID, the message and its
+// recipients so the batch resent after interruption is deduplicated by VK,
+// while the same message sent again by new broadcast is not.
+func (b *Broadcaster) batchRandomId(ids []int) int {
+	h := fnv.New32a()
+	h.Write([]byte(strconv.Itoa(b.id)))
+	h.Write([]byte(b.p.Message))
+	h.Write([]byte(b.p.Attachment))
+	h.Write([]byte(strconv.Itoa(b.p.StickerId)))
+	h.Write([]byte(IdList(ids).String()))
+	return int(h.Sum32() & 0x7fffffff)
+}
+
+func (b *Broadcaster) allowed(ids []int) (allowed []int, denied []*BroadcastResult, err error) {
+	for _, id := range ids {
+		ok, err := b.sess.MsgsIsMessagesFromGroupAllowed(b.gid, id)
+		if err != nil {
+			return nil, nil, err
+		}
+		if ok {
+			allowed = append(allowed, id)
+		} else {
+			denied = append(denied, &BroadcastResult{
+				UserId:  id,
+				ErrCode: ErrMsgsCantSend,
+				ErrMsg:  "messages from community not allowed",
+			})
+		}
+	}
+	return
+}
+
+func (b *Broadcaster) send(ids []int) ([]*BroadcastResult, error) {
+	p := b.p
+	p.UserIds = ids
+	p.RandomId = b.batchRandomId(ids)
+	rs, err := b.sess.MsgsSendMulti(&p)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*BroadcastResult, len(rs))
+	for i, r := range rs {
+		res[i] = &BroadcastResult{UserId: r.PeerId, MessageId: r.MessageId}
+		if r.Error != nil {
+			res[i].ErrCode = r.Error.Code
+			res[i].ErrMsg = r.Error.Desc
+		}
+	}
+	return res, nil
+}
+
+// Send sends the message to userIds skipping the users already recorded in
+// the state file and returns the results of all userIds. Error of a whole
+// batch stops Send, call it again with the same userIds to resume.
+func (b *Broadcaster) Send(userIds []int) ([]*BroadcastResult, error) {
+	done, id, err := b.load()
+	if err != nil {
+		return nil, err
+	}
+	var pending []int
+	seen := make(map[int]bool, len(userIds))
+	for _, id := range userIds {
+		if done[id] == nil && !seen[id] {
+			pending = append(pending, id)
+		}
+		seen[id] = true
+	}
+	f, err := os.OpenFile(b.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if id == 0 && len(done) == 0 {
+		for id == 0 {
+			if id, err = genRandomId(); err != nil {
+				return nil, err
+			}
+		}
+		line := fmt.Sprint(`{"broadcast_id":`, id, "}\n")
+		if err = writeSync(f, []byte(line)); err != nil {
+			return nil, err
+		}
+	}
+	// zero id of the state file written before broadcast ID keeps resuming
+	// with the same random_id
+	b.id = id
+	for len(pending) > 0 {
+		ids := pending
+		if len(ids) > maxSendUsers {
+			ids = ids[:maxSendUsers]
+		}
+		pending = pending[len(ids):]
+		var rs []*BroadcastResult
+		if b.CheckAllowed {
+			if ids, rs, err = b.allowed(ids); err != nil {
+				return nil, err
+			}
+		}
+		if len(ids) > 0 {
+			sent, err := b.send(ids)
+			if err != nil {
+				return nil, err
+			}
+			rs = append(rs, sent...)
+		}
+		w := bufio.NewWriter(f)
+		enc := json.NewEncoder(w)
+		for _, r := range rs {
+			if err = enc.Encode(r); err != nil {
+				return nil, err
+			}
+			done[r.UserId] = r
+		}
+		if err = w.Flush(); err != nil {
+			return nil, err
+		}
+		if err = f.Sync(); err != nil {
+			return nil, err
+		}
+	}
+	res := make([]*BroadcastResult, 0, len(userIds))
+	for _, id := range userIds {
+		if r := done[id]; r != nil {
+			res = append(res, r)
+		}
+	}
+	return res, nil
+}
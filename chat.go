@@ -0,0 +1,152 @@
+package vk
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+type (
+	// Chat is returned by messages.getChat
+	Chat struct {
+		Id       int    `json:"id"`
+		Type     string `json:"type"`
+		Title    string `json:"title"`
+		AdminId  int    `json:"admin_id"`
+		Users    []int  `json:"users"`
+		Photo50  string `json:"photo_50"`
+		Photo100 string `json:"photo_100"`
+		Photo200 string `json:"photo_200"`
+		Left     Bool   `json:"left"`
+		Kicked   Bool   `json:"kicked"`
+	}
+
+	// ChatPreview is returned by messages.getChatPreview
+	ChatPreview struct {
+		Preview struct {
+			AdminId int    `json:"admin_id"`
+			Members []int  `json:"members"`
+			Title   string `json:"title"`
+			LocalId int    `json:"local_id"`
+			Photo   struct {
+				Photo50  string `json:"photo_50"`
+				Photo100 string `json:"photo_100"`
+				Photo200 string `json:"photo_200"`
+			} `json:"photo"`
+		} `json:"preview"`
+		Profiles []User `json:"profiles"`
+	}
+)
+
+// MsgsCreateChat implements method https://vk.com/dev/messages.createChat
+// and returns the new chat ID. Use ChatPeerOffset to get its peer ID.
+func (s *Session) MsgsCreateChat(userIds []int, title string) (int, error) {
+	if len(userIds) == 0 {
+		return 0, errors.New("you must pass at least one user id")
+	}
+	vals := make(url.Values)
+	vals.Set("user_ids", IdList(userIds).String())
+	vals.Set("title", title)
+	var id int
+	if err := s.CallAPI("messages.createChat", vals, &id); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// MsgsEditChat implements method https://vk.com/dev/messages.editChat
+func (s *Session) MsgsEditChat(chatId int, title string) error {
+	vals := make(url.Values)
+	vals.Set("chat_id", strconv.Itoa(chatId))
+	vals.Set("title", title)
+	var b Bool
+	return s.CallAPI("messages.editChat", vals, &b)
+}
+
+// MsgsGetChat implements method https://vk.com/dev/messages.getChat
+func (s *Session) MsgsGetChat(chatIds []int) ([]*Chat, error) {
+	if len(chatIds) == 0 {
+		return nil, errors.New("you must pass at least one chat id")
+	}
+	vals := make(url.Values)
+	vals.Set("chat_ids", IdList(chatIds).String())
+	var cs []*Chat
+	if err := s.CallAPI("messages.getChat", vals, &cs); err != nil {
+		return nil, err
+	}
+	return cs, nil
+}
+
+// MsgsGetChatPreview implements method
+// https://vk.com/dev/messages.getChatPreview. Either peerId of a chat the
+// user is member of or invite link must be given.
+func (s *Session) MsgsGetChatPreview(peerId int, link string, fields []string) (*ChatPreview, error) {
+	vals := make(url.Values)
+	if peerId != 0 {
+		vals.Set("peer_id", strconv.Itoa(peerId))
+	}
+	if link != "" {
+		vals.Set("link", link)
+	}
+	if len(fields) > 0 {
+		vals.Set("fields", strings.Join(fields, ","))
+	}
+	var p ChatPreview
+	if err := s.CallAPI("messages.getChatPreview", vals, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// MsgsAddChatUser implements method https://vk.com/dev/messages.addChatUser
+func (s *Session) MsgsAddChatUser(chatId, userId int) error {
+	vals := make(url.Values)
+	vals.Set("chat_id", strconv.Itoa(chatId))
+	vals.Set("user_id", strconv.Itoa(userId))
+	var b Bool
+	return s.CallAPI("messages.addChatUser", vals, &b)
+}
+
+// MsgsRemoveChatUser implements method
+// https://vk.com/dev/messages.removeChatUser. memberId is negative for
+// community.
+func (s *Session) MsgsRemoveChatUser(chatId, memberId int) error {
+	vals := make(url.Values)
+	vals.Set("chat_id", strconv.Itoa(chatId))
+	vals.Set("member_id", strconv.Itoa(memberId))
+	var b Bool
+	return s.CallAPI("messages.removeChatUser", vals, &b)
+}
+
+// MsgsGetInviteLink implements method
+// https://vk.com/dev/messages.getInviteLink. reset invalidates the previous
+// link.
+func (s *Session) MsgsGetInviteLink(peerId int, reset bool) (string, error) {
+	vals := make(url.Values)
+	vals.Set("peer_id", strconv.Itoa(peerId))
+	if reset {
+		vals.Set("reset", "1")
+	}
+	var r struct {
+		Link string `json:"link"`
+	}
+	if err := s.CallAPI("messages.getInviteLink", vals, &r); err != nil {
+		return "", err
+	}
+	return r.Link, nil
+}
+
+// MsgsJoinChatByInviteLink implements method
+// https://vk.com/dev/messages.joinChatByInviteLink and returns the chat ID.
+func (s *Session) MsgsJoinChatByInviteLink(link string) (int, error) {
+	vals := make(url.Values)
+	vals.Set("link", link)
+	var r struct {
+		ChatId int `json:"chat_id"`
+	}
+	if err := s.CallAPI("messages.joinChatByInviteLink", vals, &r); err != nil {
+		return 0, err
+	}
+	return r.ChatId, nil
+}
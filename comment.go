@@ -0,0 +1,317 @@
+package vk
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+)
+
+const (
+	CommentSortAsc  = "asc"
+	CommentSortDesc = "desc"
+
+	// comment report reason
+	ReportSpam      = 0
+	ReportChildPorn = 1
+	ReportExtremism = 2
+	ReportViolence  = 3
+	ReportDrugs     = 4
+	ReportAdult     = 5
+	ReportInsult    = 6
+	ReportSuicide   = 8
+
+	maxCommentsCount = 100
+	maxThreadItems   = 10
+)
+
+type (
+	// Comments is returned by wall.getComments and wall.getComment. Profiles
+	// and Groups are only filled when extended.
+	Comments struct {
+		Count             int        `json:"count"`
+		CurrentLevelCount int        `json:"current_level_count"`
+		CanPost           bool       `json:"can_post"`
+		ShowReplyButton   bool       `json:"show_reply_button"`
+		Items             []*Comment `json:"items"`
+		Profiles          []User     `json:"profiles"`
+		Groups            []Group    `json:"groups"`
+	}
+
+	// CommentsParams is the parameters of https://vk.com/dev/wall.getComments.
+	// CommentId returns the thread of that comment instead of the post top
+	// level comments. ThreadItems is the number of thread replies returned
+	// with each top level comment (max 10).
+	CommentsParams struct {
+		Owner          Owner
+		PostId         int
+		CommentId      int
+		StartCommentId int
+		Offset         int
+		Count          int
+		Sort           string
+		NeedLikes      bool
+		PreviewLength  int
+		ThreadItems    int
+		Extended       bool
+		Fields         []string
+	}
+
+	// CommentParams is the parameters of https://vk.com/dev/wall.createComment.
+	// FromGroup is the community ID to comment on behalf of.
+	CommentParams struct {
+		Owner       Owner
+		PostId      int
+		FromGroup   int
+		Message     string
+		ReplyTo     int
+		Attachments string
+		StickerId   int
+		Guid        string
+	}
+
+	// CommentNode is a comment with its replies built by BuildCommentTree.
+	CommentNode struct {
+		*Comment
+		Replies []*CommentNode
+	}
+)
+
+// WallGetComments implements method https://vk.com/dev/wall.getComments
+func (s *Session) WallGetComments(p *CommentsParams) (*Comments, error) {
+	if p.Count > maxCommentsCount {
+		return nil, errors.New("vk: comments count is limited to 100")
+	}
+	if p.ThreadItems > maxThreadItems {
+		return nil, errors.New("vk: thread items count is limited to 10")
+	}
+	vals := make(url.Values)
+	p.Owner.set(vals)
+	vals.Set("post_id", strconv.Itoa(p.PostId))
+	if p.CommentId != 0 {
+		vals.Set("comment_id", strconv.Itoa(p.CommentId))
+	}
+	if p.StartCommentId != 0 {
+		vals.Set("start_comment_id", strconv.Itoa(p.StartCommentId))
+	}
+	if p.Offset != 0 {
+		vals.Set("offset", strconv.Itoa(p.Offset))
+	}
+	if p.Count > 0 {
+		vals.Set("count", strconv.Itoa(p.Count))
+	}
+	if p.Sort != "" {
+		vals.Set("sort", p.Sort)
+	}
+	boolVal(vals, "need_likes", p.NeedLikes)
+	if p.PreviewLength > 0 {
+		vals.Set("preview_length", strconv.Itoa(p.PreviewLength))
+	}
+	if p.ThreadItems > 0 {
+		vals.Set("thread_items_count", strconv.Itoa(p.ThreadItems))
+	}
+	setExtended(vals, p.Extended, p.Fields)
+	var c Comments
+	if err := s.CallAPI("wall.getComments", vals, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// WallGetComment implements method https://vk.com/dev/wall.getComment
+func (s *Session) WallGetComment(o Owner, id int, extended bool, fields []string) (*Comments, error) {
+	vals := make(url.Values)
+	o.set(vals)
+	vals.Set("comment_id", strconv.Itoa(id))
+	setExtended(vals, extended, fields)
+	var c Comments
+	if err := s.CallAPI("wall.getComment", vals, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// WallCreateComment implements method https://vk.com/dev/wall.createComment
+// and returns the new comment ID.
+func (s *Session) WallCreateComment(p *CommentParams) (int, error) {
+	if p.Message == "" && p.Attachments == "" && p.StickerId == 0 {
+		return 0, errors.New("vk: empty comment")
+	}
+	vals := make(url.Values)
+	p.Owner.set(vals)
+	vals.Set("post_id", strconv.Itoa(p.PostId))
+	if p.FromGroup != 0 {
+		vals.Set("from_group", strconv.Itoa(p.FromGroup))
+	}
+	if p.Message != "" {
+		vals.Set("message", p.Message)
+	}
+	if p.ReplyTo != 0 {
+		vals.Set("reply_to_comment", strconv.Itoa(p.ReplyTo))
+	}
+	if p.Attachments != "" {
+		vals.Set("attachments", p.Attachments)
+	}
+	if p.StickerId != 0 {
+		vals.Set("sticker_id", strconv.Itoa(p.StickerId))
+	}
+	if p.Guid != "" {
+		vals.Set("guid", p.Guid)
+	}
+	var r struct {
+		CommentId int `json:"comment_id"`
+	}
+	if err := s.CallAPI("wall.createComment", vals, &r); err != nil {
+		return 0, err
+	}
+	return r.CommentId, nil
+}
+
+// WallEditComment implements method https://vk.com/dev/wall.editComment
+func (s *Session) WallEditComment(o Owner, id int, m, a string) error {
+	vals := make(url.Values)
+	o.set(vals)
+	vals.Set("comment_id", strconv.Itoa(id))
+	vals.Set("message", m)
+	if a != "" {
+		vals.Set("attachments", a)
+	}
+	var b Bool
+	return s.CallAPI("wall.editComment", vals, &b)
+}
+
+func wallCommentAct(s *Session, act string, o Owner, id int) error {
+	vals := make(url.Values)
+	o.set(vals)
+	vals.Set("comment_id", strconv.Itoa(id))
+	var b Bool
+	return s.CallAPI(act, vals, &b)
+}
+
+// WallDeleteComment implements method https://vk.com/dev/wall.deleteComment
+func (s *Session) WallDeleteComment(o Owner, id int) error {
+	return wallCommentAct(s, "wall.deleteComment", o, id)
+}
+
+// WallReportComment implements method https://vk.com/dev/wall.reportComment.
+// reason is one of Report* constants.
+func (s *Session) WallReportComment(o Owner, id, reason int) error {
+	vals := make(url.Values)
+	o.set(vals)
+	vals.Set("comment_id", strconv.Itoa(id))
+	vals.Set("reason", strconv.Itoa(reason))
+	var b Bool
+	return s.CallAPI("wall.reportComment", vals, &b)
+}
+
+// PhotosDeleteComment implements method https://vk.com/dev/photos.deleteComment
+func (s *Session) PhotosDeleteComment(o Owner, id int) error {
+	return wallCommentAct(s, "photos.deleteComment", o, id)
+}
+
+// PhotosReportComment implements method https://vk.com/dev/photos.reportComment
+func (s *Session) PhotosReportComment(o Owner, id, reason int) error {
+	vals := make(url.Values)
+	o.set(vals)
+	vals.Set("comment_id", strconv.Itoa(id))
+	vals.Set("reason", strconv.Itoa(reason))
+	var b Bool
+	return s.CallAPI("photos.reportComment", vals, &b)
+}
+
+// WallRestoreComment implements method https://vk.com/dev/wall.restoreComment
+func (s *Session) WallRestoreComment(o Owner, id int) error {
+	return wallCommentAct(s, "wall.restoreComment", o, id)
+}
+
+func flattenComments(cs []*Comment, out []*Comment) []*Comment {
+	for _, c := range cs {
+		out = append(out, c)
+		if c.Thread != nil {
+			out = flattenComments(c.Thread.Items, out)
+		}
+	}
+	return out
+}
+
+// BuildCommentTree assembles comments, including their Thread items, into
+// threads keeping the given order. Parent is the last of ParentsStack or
+// ReplyToComment (API before threads). Comment which parent is not in cs is
+// a root.
+func BuildCommentTree(cs []*Comment) []*CommentNode {
+	all := flattenComments(cs, nil)
+	nodes := make(map[int]*CommentNode, len(all))
+	for _, c := range all {
+		if nodes[c.Id] == nil {
+			nodes[c.Id] = &CommentNode{Comment: c}
+		}
+	}
+	var roots []*CommentNode
+	added := make(map[int]bool, len(all))
+	for _, c := range all {
+		if added[c.Id] {
+			continue
+		}
+		added[c.Id] = true
+		n := nodes[c.Id]
+		parent := c.ReplyToComment
+		if len(c.ParentsStack) > 0 {
+			parent = c.ParentsStack[len(c.ParentsStack)-1]
+		}
+		if p := nodes[parent]; parent != 0 && p != nil && p != n {
+			p.Replies = append(p.Replies, n)
+		} else {
+			roots = append(roots, n)
+		}
+	}
+	return roots
+}
+
+func allComments(s *Session, p *CommentsParams) ([]*Comment, error) {
+	var cs []*Comment
+	p.Count = maxCommentsCount
+	for {
+		r, err := s.WallGetComments(p)
+		if err != nil {
+			return nil, err
+		}
+		cs = append(cs, r.Items...)
+		p.Offset += len(r.Items)
+		n := r.CurrentLevelCount
+		if n == 0 {
+			n = r.Count
+		}
+		if len(r.Items) < maxCommentsCount || p.Offset >= n {
+			return cs, nil
+		}
+	}
+}
+
+// WallCommentTree fetches all the comments of post id of wall o, including
+// the whole threads, and returns them as tree.
+func (s *Session) WallCommentTree(o Owner, id int) ([]*CommentNode, error) {
+	top, err := allComments(s, &CommentsParams{
+		Owner:  o,
+		PostId: id,
+		Sort:   CommentSortAsc,
+	})
+	if err != nil {
+		return nil, err
+	}
+	all := top
+	for _, c := range top {
+		if c.Thread == nil || c.Thread.Count == 0 {
+			continue
+		}
+		replies, err := allComments(s, &CommentsParams{
+			Owner:     o,
+			PostId:    id,
+			CommentId: c.Id,
+			Sort:      CommentSortAsc,
+		})
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, replies...)
+	}
+	return BuildCommentTree(all), nil
+}
@@ -0,0 +1,208 @@
+package vk
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	ConvFilterAll        = "all"
+	ConvFilterUnread     = "unread"
+	ConvFilterImportant  = "important"
+	ConvFilterUnanswered = "unanswered"
+
+	PeerTypeUser  = "user"
+	PeerTypeChat  = "chat"
+	PeerTypeGroup = "group"
+	PeerTypeEmail = "email"
+
+	maxMsgsCount = 200
+)
+
+// https://vk.com/dev/objects/conversation
+type (
+	Peer struct {
+		Id      int    `json:"id"`
+		Type    string `json:"type"`
+		LocalId int    `json:"local_id"`
+	}
+
+	ChatSettings struct {
+		MembersCount  int      `json:"members_count"`
+		Title         string   `json:"title"`
+		PinnedMessage *Message `json:"pinned_message"`
+		State         string   `json:"state"`
+		Photo         struct {
+			Photo50  string `json:"photo_50"`
+			Photo100 string `json:"photo_100"`
+			Photo200 string `json:"photo_200"`
+		} `json:"photo"`
+		ActiveIds      []int `json:"active_ids"`
+		IsGroupChannel bool  `json:"is_group_channel"`
+	}
+
+	Conversation struct {
+		Peer          Peer `json:"peer"`
+		InRead        int  `json:"in_read"`
+		OutRead       int  `json:"out_read"`
+		UnreadCount   int  `json:"unread_count"`
+		Important     bool `json:"important"`
+		Unanswered    bool `json:"unanswered"`
+		LastMessageId int  `json:"last_message_id"`
+		CanWrite      struct {
+			Allowed bool `json:"allowed"`
+			Reason  int  `json:"reason"`
+		} `json:"can_write"`
+		ChatSettings *ChatSettings `json:"chat_settings"`
+	}
+
+	ConversationItem struct {
+		Conversation *Conversation `json:"conversation"`
+		LastMessage  *Message      `json:"last_message"`
+	}
+
+	// Conversations is returned by messages.getConversations. Profiles and
+	// Groups are only filled when extended.
+	Conversations struct {
+		Count       int                 `json:"count"`
+		UnreadCount int                 `json:"unread_count"`
+		Items       []*ConversationItem `json:"items"`
+		Profiles    []User              `json:"profiles"`
+		Groups      []Group             `json:"groups"`
+	}
+
+	// ConversationsById is returned by messages.getConversationsById
+	ConversationsById struct {
+		Count    int             `json:"count"`
+		Items    []*Conversation `json:"items"`
+		Profiles []User          `json:"profiles"`
+		Groups   []Group         `json:"groups"`
+	}
+
+	// MessagesExt is messages list with the extended profiles and groups
+	// returned by messages.getHistory and messages.getById.
+	MessagesExt struct {
+		Count    int        `json:"count"`
+		Items    []*Message `json:"items"`
+		Profiles []User     `json:"profiles"`
+		Groups   []Group    `json:"groups"`
+	}
+
+	// HistoryParams is the parameters of messages.getHistory. Zero Count
+	// uses VK default (20).
+	HistoryParams struct {
+		PeerId         int
+		Offset         int
+		Count          int
+		StartMessageId int
+		// Rev true returns messages in chronological order
+		Rev      bool
+		Extended bool
+		Fields   []string
+	}
+)
+
+func setExtended(vals url.Values, extended bool, fields []string) {
+	if extended {
+		vals.Set("extended", "1")
+	}
+	if len(fields) > 0 {
+		vals.Set("fields", strings.Join(fields, ","))
+	}
+}
+
+// MsgsGetConversations implements method
+// https://vk.com/dev/messages.getConversations. filter is one of ConvFilter*
+// and empty filter means ConvFilterAll.
+func (s *Session) MsgsGetConversations(filter string, offset, count int,
+	extended bool, fields []string) (*Conversations, error) {
+	vals := make(url.Values)
+	if filter != "" {
+		vals.Set("filter", filter)
+	}
+	if offset > 0 {
+		vals.Set("offset", strconv.Itoa(offset))
+	}
+	if count > 0 {
+		vals.Set("count", strconv.Itoa(count))
+	}
+	setExtended(vals, extended, fields)
+
+	var c Conversations
+	if err := s.CallAPI("messages.getConversations", vals, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// MsgsGetConversationsById implements method
+// https://vk.com/dev/messages.getConversationsById
+func (s *Session) MsgsGetConversationsById(peerIds []int, extended bool,
+	fields []string) (*ConversationsById, error) {
+	if len(peerIds) == 0 {
+		return nil, errors.New("you must pass at least one peer id")
+	}
+	vals := make(url.Values)
+	vals.Set("peer_ids", IdList(peerIds).String())
+	setExtended(vals, extended, fields)
+
+	var c ConversationsById
+	if err := s.CallAPI("messages.getConversationsById", vals, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// MsgsGetHistory implements method https://vk.com/dev/messages.getHistory
+func (s *Session) MsgsGetHistory(p *HistoryParams) (*MessagesExt, error) {
+	if p.PeerId == 0 {
+		return nil, errors.New("vk: peer id is required")
+	}
+	if p.Count > maxMsgsCount {
+		return nil, errors.New("vk: history count is limited to 200")
+	}
+	vals := make(url.Values)
+	vals.Set("peer_id", strconv.Itoa(p.PeerId))
+	if p.Offset != 0 {
+		vals.Set("offset", strconv.Itoa(p.Offset))
+	}
+	if p.Count > 0 {
+		vals.Set("count", strconv.Itoa(p.Count))
+	}
+	if p.StartMessageId != 0 {
+		vals.Set("start_message_id", strconv.Itoa(p.StartMessageId))
+	}
+	if p.Rev {
+		vals.Set("rev", "1")
+	}
+	setExtended(vals, p.Extended, p.Fields)
+
+	var m MessagesExt
+	if err := s.CallAPI("messages.getHistory", vals, &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// MsgsGetById implements method https://vk.com/dev/messages.getById. Zero
+// previewLength returns the full text.
+func (s *Session) MsgsGetById(ids []int, previewLength int, extended bool,
+	fields []string) (*MessagesExt, error) {
+	if len(ids) == 0 {
+		return nil, errors.New("you must pass at least one message id")
+	}
+	vals := make(url.Values)
+	vals.Set("message_ids", IdList(ids).String())
+	if previewLength > 0 {
+		vals.Set("preview_length", strconv.Itoa(previewLength))
+	}
+	setExtended(vals, extended, fields)
+
+	var m MessagesExt
+	if err := s.CallAPI("messages.getById", vals, &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
@@ -0,0 +1,144 @@
+package vk
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Handler processes a single received event either from callback API or from
+// bots long poll.
+type Handler func(*ReceivedResult) error
+
+// Dispatcher routes ReceivedResult to the handler registered for its type.
+// Same Dispatcher can be fed by callback server (see Callback) and by
+// BotsLongPoll.Run.
+type Dispatcher struct {
+	handlers map[string]Handler
+	// Default is called for event type without registered handler. Nil
+	// Default ignores the event.
+	Default Handler
+}
+
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{handlers: make(map[string]Handler)}
+}
+
+// Handle registers h for event type t. Registering same type again replaces
+// the previous handler.
+func (d *Dispatcher) Handle(t string, h Handler) *Dispatcher {
+	d.handlers[t] = h
+	return d
+}
+
+// Dispatch calls the handler of rr.Type.
+func (d *Dispatcher) Dispatch(rr *ReceivedResult) error {
+	if h, ok := d.handlers[rr.Type]; ok {
+		return h(rr)
+	}
+	if d.Default != nil {
+		return d.Default(rr)
+	}
+	return nil
+}
+
+// OnPM registers h for private message event type t which is one of
+// RT_MsgNew, RT_MsgReply or RT_MsgEdit.
+func (d *Dispatcher) OnPM(t string, h func(*ReceivedResult, *Message) error) *Dispatcher {
+	return d.Handle(t, func(rr *ReceivedResult) error {
+		v, err := rr.PM()
+		if err != nil {
+			return err
+		}
+		return h(rr, v)
+	})
+}
+
+// OnWallComment registers h for wall or photo/video comment event type t
+// such as RT_WallReplyNew or RT_PhotoCommentNew.
+func (d *Dispatcher) OnWallComment(t string, h func(*ReceivedResult, *Comment) error) *Dispatcher {
+	return d.Handle(t, func(rr *ReceivedResult) error {
+		v, err := rr.WallComment()
+		if err != nil {
+			return err
+		}
+		return h(rr, v)
+	})
+}
+
+// OnWallPost registers h for RT_WallPostNew or RT_WallRepost.
+func (d *Dispatcher) OnWallPost(t string, h func(*ReceivedResult, *Post) error) *Dispatcher {
+	return d.Handle(t, func(rr *ReceivedResult) error {
+		v, err := rr.WallPost()
+		if err != nil {
+			return err
+		}
+		return h(rr, v)
+	})
+}
+
+// OnBoard registers h for board post event type t except
+// RT_BoardPostDelete.
+func (d *Dispatcher) OnBoard(t string, h func(*ReceivedResult, *TopicComment) error) *Dispatcher {
+	return d.Handle(t, func(rr *ReceivedResult) error {
+		v, err := rr.Board()
+		if err != nil {
+			return err
+		}
+		return h(rr, v)
+	})
+}
+
+func (d *Dispatcher) OnBoardDelete(h func(*ReceivedResult, *TopicDelete) error) *Dispatcher {
+	return d.Handle(RT_BoardPostDelete, func(rr *ReceivedResult) error {
+		v, err := rr.GetBoardDelete()
+		if err != nil {
+			return err
+		}
+		return h(rr, v)
+	})
+}
+
+func (d *Dispatcher) OnGroupJoin(h func(*ReceivedResult, *GroupJoin) error) *Dispatcher {
+	return d.Handle(RT_GroupJoin, func(rr *ReceivedResult) error {
+		v, err := rr.GetGroupJoin()
+		if err != nil {
+			return err
+		}
+		return h(rr, v)
+	})
+}
+
+func (d *Dispatcher) OnGroupLeave(h func(*ReceivedResult, *GroupLeave) error) *Dispatcher {
+	return d.Handle(RT_GroupLeave, func(rr *ReceivedResult) error {
+		v, err := rr.GetGroupLeave()
+		if err != nil {
+			return err
+		}
+		return h(rr, v)
+	})
+}
+
+// Callback returns http.Handler for callback API server. secret is the
+// callback secret key (empty to skip checking) and code is the confirmation
+// code replied to confirmation request (see
+// Session.GroupsGetCallbackConfirmationCode). Handler error makes the reply
+// non "ok" so VK will resend the event.
+func (d *Dispatcher) Callback(secret, code string) http.Handler {
+	rx := NewReceive(secret)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rr, err := rx.ParseRequest(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if rr.Type == RT_Confirmation {
+			fmt.Fprint(w, code)
+			return
+		}
+		if err = d.Dispatch(rr); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprint(w, "ok")
+	})
+}
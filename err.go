@@ -30,6 +30,8 @@ const (
 	ErrInvalidAppAPIID  = 101
 	ErrInvalidUserID    = 113
 	ErrInvalidTimestamp = 150
+	ErrMsgsCantSend     = 901 // user does not allow messages from community
+	ErrMsgsPrivacy      = 902 // user privacy settings
 )
 
 type Error struct {
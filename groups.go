@@ -3,6 +3,18 @@ package vk
 import (
 	"encoding/json"
 	"io"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	// groups.ban reason
+	BanOther    = 0
+	BanSpam     = 1
+	BanAbuse    = 2
+	BanObscene  = 3
+	BanOffTopic = 4
 )
 
 type (
@@ -80,3 +92,22 @@ func (s *SmallGroups) More() (*SmallGroup, error) {
 func NewGroupLoop(s *SmallGroups) GroupLooper {
 	return s
 }
+
+// GroupsBan implements method https://vk.com/dev/groups.ban. owner is user
+// or community to ban from community gid. Zero end bans forever.
+func (s *Session) GroupsBan(gid int, owner Owner, end time.Time, reason int,
+	comment string, commentVisible bool) error {
+	vals := make(url.Values)
+	vals.Set("group_id", strconv.Itoa(gid))
+	vals.Set("owner_id", owner.String())
+	if !end.IsZero() {
+		vals.Set("end_date", strconv.FormatInt(end.Unix(), 10))
+	}
+	vals.Set("reason", strconv.Itoa(reason))
+	if comment != "" {
+		vals.Set("comment", comment)
+	}
+	boolVal(vals, "comment_visible", commentVisible)
+	var b Bool
+	return s.CallAPI("groups.ban", vals, &b)
+}
@@ -2,40 +2,71 @@ package vk
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/url"
+	"os"
 	"strconv"
 )
 
-func ownerOptions(vals url.Values, v ...interface{}) (isGroup bool) {
-	if v != nil {
-		vSize := len(v)
-		if vSize > 0 {
-			if id, ok := v[0].(string); ok && id != "" {
-				if vSize > 1 {
-					if isCommunity, ok := v[1].(bool); ok {
-						if isCommunity {
-							id = fmt.Sprint("-", id)
-							isGroup = true
-						}
-					}
-				}
-				vals.Set("owner_id", id)
-			} else if id, ok := v[0].(int); ok && id > 0 {
-				if vSize > 1 {
-					if isCommunity, ok := v[1].(bool); ok {
-						if isCommunity {
-							id = -1 * id
-							isGroup = true
-						}
-					}
-				}
-				vals.Set("owner_id", strconv.Itoa(id))
-			}
-		}
+// Owner is the owner ID of wall, post, photo and the like. Positive Owner is
+// user and negative Owner is community. Zero Owner is the current user of the
+// session token.
+type Owner int
+
+// NewOwner creates Owner of user or community id. id must be positive.
+func NewOwner(id int, isCommunity bool) (Owner, error) {
+	if id <= 0 {
+		return 0, errors.New(fmt.Sprint("vk: invalid owner id: ", id))
+	}
+	if isCommunity {
+		return Owner(-id), nil
+	}
+	return Owner(id), nil
+}
+
+// CommunityOwner creates Owner of community gid which can be given either as
+// positive group ID or as negative owner ID.
+func CommunityOwner(gid int) Owner {
+	if gid > 0 {
+		return Owner(-gid)
+	}
+	return Owner(gid)
+}
+
+func (o Owner) IsCommunity() bool {
+	return o < 0
+}
+
+// Id returns the user or community ID without sign.
+func (o Owner) Id() int {
+	if o < 0 {
+		return int(-o)
+	}
+	return int(o)
+}
+
+func (o Owner) String() string {
+	return strconv.Itoa(int(o))
+}
+
+// set sets owner_id of vals unless o is the current user.
+func (o Owner) set(vals url.Values) {
+	if o != 0 {
+		vals.Set("owner_id", o.String())
+	}
+}
+
+// writeFileAtomic writes b to a temporary file and renames it to path, so
+// crash never leaves path truncated.
+func writeFileAtomic(path string, b []byte) error {
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0600); err != nil {
+		return err
 	}
-	return
+	return os.Rename(tmp, path)
 }
 
 func unmarshaler(v interface{}, r io.Reader) error {
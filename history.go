@@ -0,0 +1,171 @@
+package vk
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+// HistoryCursor is the position of HistoryLooper. It can be saved and passed
+// to NewHistoryLoop to resume paging later.
+type HistoryCursor struct {
+	PeerId int `json:"peer_id"`
+	// Forward pages from the oldest to the newest message.
+	Forward bool `json:"forward"`
+	// LastId is the ID of the last returned message.
+	LastId int `json:"last_id"`
+	// Offset is the number of returned messages when Forward.
+	Offset int `json:"offset"`
+}
+
+type HistoryLooper interface {
+	More() (*Message, error)
+	Cursor() HistoryCursor
+}
+
+// historyLoop pages messages.getHistory. Backward paging starts from the
+// last returned message ID and forward paging uses reversed order offset, so
+// new messages arriving meanwhile never shift the pages. Calls are limited by
+// the same delayer as other CallAPI.
+type historyLoop struct {
+	sess  *Session
+	c     HistoryCursor
+	items []*Message
+	done  bool
+}
+
+// NewHistoryLoop creates HistoryLooper over the whole history of c.PeerId in
+// the direction of c.Forward. Pass saved cursor to resume.
+func NewHistoryLoop(s *Session, c HistoryCursor) HistoryLooper {
+	return &historyLoop{sess: s, c: c}
+}
+
+func (h *historyLoop) Cursor() HistoryCursor {
+	return h.c
+}
+
+func (h *historyLoop) fetch() error {
+	p := &HistoryParams{PeerId: h.c.PeerId, Count: maxMsgsCount}
+	if h.c.Forward {
+		p.Rev = true
+		p.Offset = h.c.Offset
+	} else if h.c.LastId != 0 {
+		p.StartMessageId = h.c.LastId
+		p.Offset = 1
+	}
+	m, err := h.sess.MsgsGetHistory(p)
+	if err != nil {
+		return err
+	}
+	if len(m.Items) < maxMsgsCount {
+		h.done = true
+	}
+	for _, msg := range m.Items {
+		// skip the messages returned before when resumed forward
+		if h.c.Forward && h.c.LastId != 0 && msg.Id <= h.c.LastId {
+			h.c.Offset++
+			continue
+		}
+		h.items = append(h.items, msg)
+	}
+	return nil
+}
+
+func (h *historyLoop) More() (*Message, error) {
+	for len(h.items) == 0 {
+		if h.done {
+			return nil, io.EOF
+		}
+		if err := h.fetch(); err != nil {
+			return nil, err
+		}
+	}
+	m := h.items[0]
+	h.items = h.items[1:]
+	h.c.LastId = m.Id
+	if h.c.Forward {
+		h.c.Offset++
+	}
+	return m, nil
+}
+
+// decodeAttachments fills the content URL of the known attachments.
+func decodeAttachments(as []*Attachment) {
+	for _, a := range as {
+		switch {
+		case a.Type == AT_Photo && a.P != nil:
+			a.Photo()
+		case a.Type == AT_Sticker && a.S != nil:
+			a.Sticker()
+		case a.Type == AT_Video && a.V != nil:
+			a.Video()
+		case a.Type == AT_Audio && a.A != nil:
+			a.Audio()
+		case a.Type == AT_Doc && a.D != nil:
+			a.Doc()
+		case a.Type == AT_AudioMsg && a.AM != nil:
+			a.AudioMsg()
+		case a.Type == AT_Graffiti && a.Gr != nil:
+			a.Graffiti()
+		}
+	}
+}
+
+func decodeMsgAttachments(m *Message) {
+	decodeAttachments(m.Attachments)
+	for _, f := range m.Fwd {
+		decodeMsgAttachments(f)
+	}
+	if m.Reply != nil {
+		decodeMsgAttachments(m.Reply)
+	}
+}
+
+// ExportHistory writes the whole conversation with peerId, from the oldest
+// message, to file path as JSON lines of Message with decoded attachments.
+// The cursor is saved next to the file (path + ".cursor") after every
+// message so interrupted export continues where it stopped.
+func ExportHistory(s *Session, peerId int, path string) error {
+	cpath := path + ".cursor"
+	c := HistoryCursor{PeerId: peerId, Forward: true}
+	if b, err := ioutil.ReadFile(cpath); err == nil {
+		if err = json.Unmarshal(b, &c); err != nil {
+			return err
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	l := NewHistoryLoop(s, c)
+	for {
+		m, err := l.More()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		decodeMsgAttachments(m)
+		if err = enc.Encode(m); err != nil {
+			return err
+		}
+		if err = w.Flush(); err != nil {
+			return err
+		}
+		b, err := json.Marshal(l.Cursor())
+		if err != nil {
+			return err
+		}
+		if err = writeFileAtomic(cpath, b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
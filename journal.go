@@ -0,0 +1,333 @@
+package vk
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+const (
+	journalEventsFile = "events.log"
+	journalAcksFile   = "acks.log"
+)
+
+type (
+	// JournalEntry is a persisted received event and its journal offset.
+	JournalEntry struct {
+		Offset int64           `json:"offset"`
+		Result *ReceivedResult `json:"event"`
+	}
+
+	// Journal is append-only store of received events. Event is appended
+	// before it is acknowledged to VK and acked after it has been processed,
+	// so the events lost by crashed processor can be replayed.
+	Journal interface {
+		Append(*ReceivedResult) (int64, error)
+		Ack(offset int64) error
+		// Pending returns the appended but not acked entries in order.
+		Pending() ([]*JournalEntry, error)
+		Close() error
+	}
+)
+
+// Journaled wraps h so every event is appended to j before h is called and
+// acked when h succeeds. Only the append error is returned so the event is
+// acknowledged to VK once it is safely stored. Event failed by h stays
+// pending and is processed again by Replay.
+func Journaled(j Journal, h Handler) Handler {
+	return func(rr *ReceivedResult) error {
+		off, err := j.Append(rr)
+		if err != nil {
+			return err
+		}
+		if err = h(rr); err != nil {
+			if Debug {
+				fmt.Printf("vk journal entry %d left pending: %v\n", off, err)
+			}
+			return nil
+		}
+		return j.Ack(off)
+	}
+}
+
+// Replay passes the pending entries of j to h and acks each of them that h
+// processes successfully. It stops at the first error of h. Call it at
+// start up before receiving new events.
+func Replay(j Journal, h Handler) error {
+	es, err := j.Pending()
+	if err != nil {
+		return err
+	}
+	for _, e := range es {
+		if err = h(e.Result); err != nil {
+			return err
+		}
+		if err = j.Ack(e.Offset); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FileJournal is a Journal kept in a directory as two append-only JSON lines
+// files: one for events and one for acked offsets. Every write is synced to
+// disk before it returns.
+type FileJournal struct {
+	dir    string
+	events *os.File
+	acks   *os.File
+	next   int64
+	acked  map[int64]bool
+	sync.Mutex
+}
+
+// OpenFileJournal opens or creates FileJournal in directory dir.
+func OpenFileJournal(dir string) (*FileJournal, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	j := &FileJournal{dir: dir, acked: make(map[int64]bool)}
+	if err := j.open(); err != nil {
+		return nil, err
+	}
+	es, err := j.readEvents()
+	if err != nil {
+		j.Close()
+		return nil, err
+	}
+	if n := len(es); n > 0 {
+		j.next = es[n-1].Offset + 1
+	}
+	// acks are left when crashed after compacting the events, so their
+	// offsets must not be given to new events
+	for off := range j.acked {
+		if off >= j.next {
+			j.next = off + 1
+		}
+	}
+	return j, nil
+}
+
+func (j *FileJournal) open() error {
+	var err error
+	flag := os.O_CREATE | os.O_APPEND | os.O_RDWR
+	j.events, err = os.OpenFile(filepath.Join(j.dir, journalEventsFile), flag, 0600)
+	if err != nil {
+		return err
+	}
+	j.acks, err = os.OpenFile(filepath.Join(j.dir, journalAcksFile), flag, 0600)
+	if err == nil {
+		err = truncateTorn(j.events)
+	}
+	if err == nil {
+		err = truncateTorn(j.acks)
+	}
+	if err == nil {
+		err = j.readAcks()
+	}
+	if err != nil {
+		j.events.Close()
+		if j.acks != nil {
+			j.acks.Close()
+		}
+	}
+	return err
+}
+
+// truncateTorn cuts the incomplete last line left by crashed write so the
+// next append starts on a new line.
+func truncateTorn(f *os.File) error {
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	const chunk = 4096
+	buf := make([]byte, chunk)
+	end := fi.Size()
+	for end > 0 {
+		start := end - chunk
+		if start < 0 {
+			start = 0
+		}
+		n, err := f.ReadAt(buf[:end-start], start)
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if i := bytes.LastIndexByte(buf[:n], '\n'); i >= 0 {
+			end = start + int64(i) + 1
+			break
+		}
+		end = start
+	}
+	if end == fi.Size() {
+		return nil
+	}
+	return f.Truncate(end)
+}
+
+func (j *FileJournal) readAcks() error {
+	f, err := os.Open(j.acks.Name())
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			// line without new line is incomplete write
+			return nil
+		} else if err != nil {
+			return err
+		}
+		off, err := strconv.ParseInt(string(line[:len(line)-1]), 10, 64)
+		if err != nil {
+			continue
+		}
+		j.acked[off] = true
+	}
+}
+
+func (j *FileJournal) readEvents() ([]*JournalEntry, error) {
+	f, err := os.Open(j.events.Name())
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var es []*JournalEntry
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err != nil {
+			// last line without new line is incomplete write
+			break
+		}
+		e := new(JournalEntry)
+		if json.Unmarshal(line, e) != nil {
+			continue
+		}
+		es = append(es, e)
+	}
+	return es, nil
+}
+
+func writeSync(f *os.File, b []byte) error {
+	if _, err := f.Write(b); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+func (j *FileJournal) Append(rr *ReceivedResult) (int64, error) {
+	j.Lock()
+	defer j.Unlock()
+	e := &JournalEntry{Offset: j.next, Result: rr}
+	b, err := json.Marshal(e)
+	if err != nil {
+		return 0, err
+	}
+	if err = writeSync(j.events, append(b, '\n')); err != nil {
+		return 0, err
+	}
+	j.next++
+	return e.Offset, nil
+}
+
+func (j *FileJournal) Ack(offset int64) error {
+	j.Lock()
+	defer j.Unlock()
+	if j.acked[offset] {
+		return nil
+	}
+	b := strconv.AppendInt(nil, offset, 10)
+	if err := writeSync(j.acks, append(b, '\n')); err != nil {
+		return err
+	}
+	j.acked[offset] = true
+	return nil
+}
+
+func (j *FileJournal) Pending() ([]*JournalEntry, error) {
+	j.Lock()
+	defer j.Unlock()
+	return j.pendingLocked()
+}
+
+// pendingLocked is Pending for the caller holding the lock.
+func (j *FileJournal) pendingLocked() ([]*JournalEntry, error) {
+	es, err := j.readEvents()
+	if err != nil {
+		return nil, err
+	}
+	pending := es[:0]
+	for _, e := range es {
+		if !j.acked[e.Offset] {
+			pending = append(pending, e)
+		}
+	}
+	return pending, nil
+}
+
+// Compact rewrites the journal with only the pending entries and empties the
+// acked offsets. Offsets of the pending entries are kept.
+func (j *FileJournal) Compact() error {
+	j.Lock()
+	defer j.Unlock()
+	// the lock is held till the rename so no append goes to the old file
+	es, err := j.pendingLocked()
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(j.dir, journalEventsFile+".tmp")
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, e := range es {
+		if err = enc.Encode(e); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err = w.Flush(); err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	j.events.Close()
+	j.acks.Close()
+	if err = os.Rename(tmp, filepath.Join(j.dir, journalEventsFile)); err != nil {
+		os.Remove(tmp)
+		j.open()
+		return err
+	}
+	if err = os.Truncate(filepath.Join(j.dir, journalAcksFile), 0); err != nil {
+		// acked offsets are still valid for the compacted events
+		j.open()
+		return err
+	}
+	j.acked = make(map[int64]bool)
+	return j.open()
+}
+
+func (j *FileJournal) Close() error {
+	j.Lock()
+	defer j.Unlock()
+	err := j.events.Close()
+	if aerr := j.acks.Close(); err == nil {
+		err = aerr
+	}
+	return err
+}
@@ -0,0 +1,165 @@
+package vk
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func openTestJournal(t *testing.T, dir string) *FileJournal {
+	j, err := OpenFileJournal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return j
+}
+
+func appendN(t *testing.T, j Journal, n int) []int64 {
+	offs := make([]int64, n)
+	for i := range offs {
+		off, err := j.Append(&ReceivedResult{Type: RT_MsgNew})
+		if err != nil {
+			t.Fatal(err)
+		}
+		offs[i] = off
+	}
+	return offs
+}
+
+func pendingOffsets(t *testing.T, j Journal) []int64 {
+	es, err := j.Pending()
+	if err != nil {
+		t.Fatal(err)
+	}
+	offs := make([]int64, len(es))
+	for i, e := range es {
+		offs[i] = e.Offset
+	}
+	return offs
+}
+
+func appendFile(t *testing.T, path, s string) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err = f.WriteString(s); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestJournalPending(t *testing.T) {
+	dir := t.TempDir()
+	j := openTestJournal(t, dir)
+	offs := appendN(t, j, 3)
+	if err := j.Ack(offs[1]); err != nil {
+		t.Fatal(err)
+	}
+	if p := pendingOffsets(t, j); len(p) != 2 || p[0] != offs[0] || p[1] != offs[2] {
+		t.Fatalf("pending %v", p)
+	}
+	j.Close()
+
+	j = openTestJournal(t, dir)
+	defer j.Close()
+	if p := pendingOffsets(t, j); len(p) != 2 {
+		t.Fatalf("pending after reopen %v", p)
+	}
+	if off := appendN(t, j, 1)[0]; off != offs[2]+1 {
+		t.Fatalf("offset after reopen %d", off)
+	}
+}
+
+func TestJournalTornLines(t *testing.T) {
+	dir := t.TempDir()
+	j := openTestJournal(t, dir)
+	appendN(t, j, 13)
+	for off := int64(0); off < 12; off++ {
+		if err := j.Ack(off); err != nil {
+			t.Fatal(err)
+		}
+	}
+	j.Close()
+	// crash in the middle of writing event 13 and rewriting ack 12 as "1"
+	appendFile(t, filepath.Join(dir, journalEventsFile), `{"offset":13,"ev`)
+	if err := ioutil.WriteFile(filepath.Join(dir, journalAcksFile), []byte("0\n1"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	j = openTestJournal(t, dir)
+	defer j.Close()
+	appendN(t, j, 1)
+	// 1 to 12 are not acked any more, plus the new event
+	if p := pendingOffsets(t, j); len(p) != 13 || p[0] != 1 || p[12] != 13 {
+		t.Fatalf("pending %v", p)
+	}
+}
+
+func TestJournalCompactConcurrentAppend(t *testing.T) {
+	const (
+		writers = 4
+		perW    = 200
+	)
+	j := openTestJournal(t, t.TempDir())
+	defer j.Close()
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perW; i++ {
+				if _, err := j.Append(&ReceivedResult{Type: RT_MsgNew}); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	for compacting := true; compacting; {
+		select {
+		case <-done:
+			compacting = false
+		default:
+		}
+		if err := j.Compact(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if p := pendingOffsets(t, j); len(p) != writers*perW {
+		t.Fatalf("%d pending of %d", len(p), writers*perW)
+	}
+}
+
+func TestJournalOffsetsAfterCompactCrash(t *testing.T) {
+	dir := t.TempDir()
+	j := openTestJournal(t, dir)
+	offs := appendN(t, j, 3)
+	for _, off := range offs {
+		if err := j.Ack(off); err != nil {
+			t.Fatal(err)
+		}
+	}
+	j.Close()
+	// crash after the compacted events renamed but before acks truncated
+	if err := os.Truncate(filepath.Join(dir, journalEventsFile), 0); err != nil {
+		t.Fatal(err)
+	}
+
+	j = openTestJournal(t, dir)
+	defer j.Close()
+	off := appendN(t, j, 1)[0]
+	if off <= offs[2] {
+		t.Fatalf("offset %d reused", off)
+	}
+	if p := pendingOffsets(t, j); len(p) != 1 || p[0] != off {
+		t.Fatalf("pending %v", p)
+	}
+}
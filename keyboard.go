@@ -0,0 +1,237 @@
+package vk
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+const (
+	// keyboard button action type
+	BT_Text     = "text"
+	BT_OpenLink = "open_link"
+	BT_Location = "location"
+	BT_VKPay    = "vkpay"
+	BT_Callback = "callback"
+
+	// keyboard button color, only for BT_Text and BT_Callback
+	BC_Primary   = "primary"
+	BC_Secondary = "secondary"
+	BC_Negative  = "negative"
+	BC_Positive  = "positive"
+
+	KbMaxRowButtons    = 5
+	KbMaxRows          = 10
+	KbMaxInlineRows    = 6
+	KbMaxButtons       = 40
+	KbMaxInlineButtons = 10
+	kbMaxLabel         = 40
+	kbMaxPayload       = 255
+)
+
+type (
+	// Keyboard is the bot keyboard of https://vk.com/dev/bots_docs_3 sent
+	// through MsgSendParams.Keyboard. Build it with NewKeyboard, Row and the
+	// Add methods.
+	Keyboard struct {
+		OneTime bool        `json:"one_time"`
+		Inline  bool        `json:"inline,omitempty"`
+		Buttons [][]*Button `json:"buttons"`
+	}
+
+	Button struct {
+		Action ButtonAction `json:"action"`
+		Color  string       `json:"color,omitempty"`
+	}
+
+	ButtonAction struct {
+		Type    string `json:"type"`
+		Label   string `json:"label,omitempty"`
+		Payload string `json:"payload,omitempty"`
+		Link    string `json:"link,omitempty"`
+		Hash    string `json:"hash,omitempty"`
+	}
+)
+
+// NewKeyboard creates keyboard with one empty row. oneTime keyboard is hidden
+// after a button is pressed and inline keyboard is attached to the message.
+// Keyboard can not be both.
+func NewKeyboard(oneTime, inline bool) *Keyboard {
+	return &Keyboard{
+		OneTime: oneTime,
+		Inline:  inline,
+		Buttons: [][]*Button{{}},
+	}
+}
+
+// Row starts a new row of buttons.
+func (kb *Keyboard) Row() *Keyboard {
+	kb.Buttons = append(kb.Buttons, []*Button{})
+	return kb
+}
+
+func (kb *Keyboard) add(b *Button) *Keyboard {
+	if len(kb.Buttons) == 0 {
+		kb.Buttons = [][]*Button{{}}
+	}
+	i := len(kb.Buttons) - 1
+	kb.Buttons[i] = append(kb.Buttons[i], b)
+	return kb
+}
+
+// AddText adds text button to current row. payload is JSON string returned
+// in Message.Payload when the button is pressed and can be empty.
+func (kb *Keyboard) AddText(label, payload, color string) *Keyboard {
+	return kb.add(&Button{
+		Action: ButtonAction{Type: BT_Text, Label: label, Payload: payload},
+		Color:  color,
+	})
+}
+
+// AddCallback adds callback button which press is received as
+// RT_MsgEvent event instead of message.
+func (kb *Keyboard) AddCallback(label, payload, color string) *Keyboard {
+	return kb.add(&Button{
+		Action: ButtonAction{Type: BT_Callback, Label: label, Payload: payload},
+		Color:  color,
+	})
+}
+
+func (kb *Keyboard) AddOpenLink(label, link, payload string) *Keyboard {
+	return kb.add(&Button{
+		Action: ButtonAction{Type: BT_OpenLink, Label: label, Link: link,
+			Payload: payload},
+	})
+}
+
+// AddLocation adds send location button. It must be alone in its row.
+func (kb *Keyboard) AddLocation(payload string) *Keyboard {
+	return kb.add(&Button{
+		Action: ButtonAction{Type: BT_Location, Payload: payload},
+	})
+}
+
+// AddVKPay adds VK Pay button with hash of the payment parameters. It must
+// be alone in its row.
+func (kb *Keyboard) AddVKPay(hash, payload string) *Keyboard {
+	return kb.add(&Button{
+		Action: ButtonAction{Type: BT_VKPay, Hash: hash, Payload: payload},
+	})
+}
+
+func (b *Button) validate() error {
+	a := &b.Action
+	switch a.Type {
+	case BT_Text, BT_Callback:
+		if a.Label == "" {
+			return errors.New("vk: keyboard button without label")
+		}
+	case BT_OpenLink:
+		if a.Link == "" {
+			return errors.New("vk: keyboard open_link button without link")
+		}
+		if a.Label == "" {
+			return errors.New("vk: keyboard open_link button without label")
+		}
+	case BT_VKPay:
+		if a.Hash == "" {
+			return errors.New("vk: keyboard vkpay button without hash")
+		}
+	case BT_Location:
+	default:
+		return errors.New(fmt.Sprint("vk: unknown keyboard button type: ", a.Type))
+	}
+	if utf8.RuneCountInString(a.Label) > kbMaxLabel {
+		return errors.New(fmt.Sprint("vk: keyboard button label too long: ", a.Label))
+	}
+	if b.Color != "" && a.Type != BT_Text && a.Type != BT_Callback {
+		return errors.New(fmt.Sprint("vk: keyboard ", a.Type, " button can not have color"))
+	}
+	if a.Payload != "" {
+		if len(a.Payload) > kbMaxPayload {
+			return errors.New("vk: keyboard button payload too long")
+		}
+		if !json.Valid([]byte(a.Payload)) {
+			return errors.New("vk: keyboard button payload is not JSON")
+		}
+	}
+	return nil
+}
+
+// Validate checks kb against VK keyboard limits. Empty row is ignored when
+// kb is marshaled.
+func (kb *Keyboard) Validate() error {
+	if kb.Inline && kb.OneTime {
+		return errors.New("vk: inline keyboard can not be one time")
+	}
+	maxRows, maxButtons := KbMaxRows, KbMaxButtons
+	if kb.Inline {
+		maxRows, maxButtons = KbMaxInlineRows, KbMaxInlineButtons
+	}
+	var rows, total int
+	for _, row := range kb.Buttons {
+		if len(row) == 0 {
+			continue
+		}
+		rows++
+		if len(row) > KbMaxRowButtons {
+			return errors.New(fmt.Sprint("vk: keyboard row has more than ",
+				KbMaxRowButtons, " buttons"))
+		}
+		for _, b := range row {
+			if err := b.validate(); err != nil {
+				return err
+			}
+			t := b.Action.Type
+			if (t == BT_Location || t == BT_VKPay) && len(row) > 1 {
+				return errors.New(fmt.Sprint("vk: keyboard ", t,
+					" button must be alone in its row"))
+			}
+		}
+		total += len(row)
+	}
+	if rows > maxRows {
+		return errors.New(fmt.Sprint("vk: keyboard has more than ", maxRows, " rows"))
+	}
+	if total > maxButtons {
+		return errors.New(fmt.Sprint("vk: keyboard has more than ", maxButtons, " buttons"))
+	}
+	return nil
+}
+
+// MarshalJSON skips the empty rows.
+func (kb *Keyboard) MarshalJSON() ([]byte, error) {
+	rows := make([][]*Button, 0, len(kb.Buttons))
+	for _, row := range kb.Buttons {
+		if len(row) > 0 {
+			rows = append(rows, row)
+		}
+	}
+	type keyboard Keyboard
+	return json.Marshal(&keyboard{
+		OneTime: kb.OneTime,
+		Inline:  kb.Inline,
+		Buttons: rows,
+	})
+}
+
+// JSON validates kb and returns the value of messages.send keyboard param.
+func (kb *Keyboard) JSON() (string, error) {
+	if err := kb.Validate(); err != nil {
+		return "", err
+	}
+	b, err := json.Marshal(kb)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// DecodePayload decodes the payload of keyboard button which sent m.
+func (m *Message) DecodePayload(v interface{}) error {
+	if m.Payload == "" {
+		return errors.New("vk: message without payload")
+	}
+	return json.Unmarshal([]byte(m.Payload), v)
+}
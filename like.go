@@ -1,6 +1,9 @@
 package vk
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/url"
 	"strconv"
 )
@@ -10,6 +13,13 @@ type LikeType int
 const (
 	likesAdd = "likes.add"
 	likesDel = "likes.delete"
+
+	// likes.getList filter
+	LikesFilterLikes  = "likes"
+	LikesFilterCopies = "copies"
+
+	maxLikers         = 1000
+	maxLikersExtended = 100
 )
 
 const (
@@ -22,6 +32,10 @@ const (
 	LikesPhotoComment
 	LikesVideoComment
 	LikesTopicComment
+	LikesMarket
+	LikesMarketComment
+	LikesSitepage
+	LikesStory
 )
 
 var likeType = []string{
@@ -34,34 +48,41 @@ var likeType = []string{
 	"photo_comment",
 	"video_comment",
 	"topic_comment",
+	"market",
+	"market_comment",
+	"sitepage",
+	"story",
 }
 
+// String returns VK type name of l or "LikeType(n)" for unknown types.
 func (l LikeType) String() string {
+	if !l.valid() {
+		return fmt.Sprint("LikeType(", int(l), ")")
+	}
 	return likeType[int(l)]
 }
 
-func likeUnlike(s *Session, act string, t LikeType, id int,
-	likesOptions ...interface{}) (int, error) {
+func (l LikeType) valid() bool {
+	return l >= 0 && int(l) < len(likeType)
+}
+
+func invalidLikeType(t LikeType) error {
+	return errors.New(fmt.Sprint("vk: invalid like type: ", int(t)))
+}
+
+// likeUnlike adds or deletes like of item id of owner o. accessKey is needed
+// for private items.
+func likeUnlike(s *Session, act string, t LikeType, o Owner, id int,
+	accessKey string) (int, error) {
+	if !t.valid() {
+		return 0, invalidLikeType(t)
+	}
 	vals := url.Values{}
 	vals.Set("type", t.String())
 	vals.Set("item_id", strconv.Itoa(id))
-	if likesOptions != nil {
-		optSize := len(likesOptions)
-		if optSize > 1 {
-			if idInt, ok := likesOptions[0].(int); ok && idInt > 0 {
-				if isCommunity, ok := likesOptions[1].(bool); ok {
-					if isCommunity {
-						idInt = -1 * idInt
-					}
-				}
-				vals.Set("owner_id", strconv.Itoa(idInt))
-			}
-		}
-		if optSize > 2 {
-			if ak, ok := likesOptions[2].(string); ok && ak != "" {
-				vals.Set("access_key", ak)
-			}
-		}
+	o.set(vals)
+	if accessKey != "" {
+		vals.Set("access_key", accessKey)
 	}
 	var n struct {
 		Likes int `json:"likes"`
@@ -72,10 +93,166 @@ func likeUnlike(s *Session, act string, t LikeType, id int,
 	return n.Likes, nil
 }
 
-func (s *Session) Likes(t LikeType, id int, likesOptions ...interface{}) (int, error) {
-	return likeUnlike(s, likesAdd, t, id, likesOptions...)
+func (s *Session) Likes(t LikeType, o Owner, id int, accessKey string) (int, error) {
+	return likeUnlike(s, likesAdd, t, o, id, accessKey)
+}
+
+func (s *Session) Unlikes(t LikeType, o Owner, id int, accessKey string) (int, error) {
+	return likeUnlike(s, likesDel, t, o, id, accessKey)
+}
+
+type (
+	// Liker is the user or community returned by likes.getList. Only Id is
+	// set unless extended is requested. Type is "profile" or "group" and
+	// Name is only set for community.
+	Liker struct {
+		Type      string `json:"type"`
+		Id        int    `json:"id"`
+		FirstName string `json:"first_name"`
+		LastName  string `json:"last_name"`
+		Name      string `json:"name"`
+	}
+
+	// LikersParams is the parameters of https://vk.com/dev/likes.getList.
+	// Zero Count uses VK default (100).
+	LikersParams struct {
+		Type        LikeType
+		Owner       Owner
+		ItemId      int
+		PageUrl     string // only for LikesSitepage
+		Filter      string // LikesFilterLikes or LikesFilterCopies
+		FriendsOnly bool
+		Extended    bool
+		SkipOwn     bool
+		Offset      int
+		Count       int
+	}
+)
+
+// LikesGetList implements method https://vk.com/dev/likes.getList and
+// returns the total count and the likers of p.
+func (s *Session) LikesGetList(p *LikersParams) (int, []Liker, error) {
+	if !p.Type.valid() {
+		return 0, nil, invalidLikeType(p.Type)
+	}
+	max := maxLikers
+	if p.Extended {
+		max = maxLikersExtended
+	}
+	if p.Count > max {
+		return 0, nil, errors.New(fmt.Sprint("vk: likers count is limited to ", max))
+	}
+	vals := make(url.Values)
+	vals.Set("type", p.Type.String())
+	p.Owner.set(vals)
+	if p.ItemId != 0 {
+		vals.Set("item_id", strconv.Itoa(p.ItemId))
+	}
+	if p.PageUrl != "" {
+		vals.Set("page_url", p.PageUrl)
+	}
+	if p.Filter != "" {
+		vals.Set("filter", p.Filter)
+	}
+	boolVal(vals, "friends_only", p.FriendsOnly)
+	boolVal(vals, "extended", p.Extended)
+	boolVal(vals, "skip_own", p.SkipOwn)
+	if p.Offset > 0 {
+		vals.Set("offset", strconv.Itoa(p.Offset))
+	}
+	if p.Count > 0 {
+		vals.Set("count", strconv.Itoa(p.Count))
+	}
+	var r struct {
+		Count int               `json:"count"`
+		Items []json.RawMessage `json:"items"`
+	}
+	if err := s.CallAPI("likes.getList", vals, &r); err != nil {
+		return 0, nil, err
+	}
+	ls := make([]Liker, len(r.Items))
+	for i, b := range r.Items {
+		// items are plain user ids unless extended
+		var err error
+		if len(b) > 0 && b[0] == '{' {
+			err = json.Unmarshal(b, &ls[i])
+		} else {
+			err = json.Unmarshal(b, &ls[i].Id)
+		}
+		if err != nil {
+			return 0, nil, err
+		}
+	}
+	return r.Count, ls, nil
+}
+
+// LikesIsLiked implements method https://vk.com/dev/likes.isLiked and
+// reports whether user uid liked and reposted the item. Zero uid checks the
+// current user.
+func (s *Session) LikesIsLiked(uid int, t LikeType, o Owner,
+	id int) (liked, copied bool, err error) {
+	if !t.valid() {
+		return false, false, invalidLikeType(t)
+	}
+	vals := make(url.Values)
+	if uid != 0 {
+		vals.Set("user_id", strconv.Itoa(uid))
+	}
+	vals.Set("type", t.String())
+	o.set(vals)
+	vals.Set("item_id", strconv.Itoa(id))
+	var r struct {
+		Liked  Bool `json:"liked"`
+		Copied Bool `json:"copied"`
+	}
+	if err = s.CallAPI("likes.isLiked", vals, &r); err != nil {
+		return
+	}
+	return bool(r.Liked), bool(r.Copied), nil
+}
+
+type LikerLooper interface {
+	More() (*Liker, error)
+	Size() int
 }
 
-func (s *Session) Unlikes(t LikeType, id int, likesOptions ...interface{}) (int, error) {
-	return likeUnlike(s, likesDel, t, id, likesOptions...)
+type likerLoop struct {
+	pager
+	sess  *Session
+	p     LikersParams
+	items []Liker
+}
+
+// NewLikersLoop creates LikerLooper over all the likers of p starting at
+// p.Offset. Size is known after the first More.
+func NewLikersLoop(s *Session, p *LikersParams) LikerLooper {
+	l := &likerLoop{sess: s, p: *p}
+	if p.Extended {
+		l.p.Count = maxLikersExtended
+	} else {
+		l.p.Count = maxLikers
+	}
+	l.size, l.offset = l.p.Count, p.Offset
+	return l
+}
+
+func (l *likerLoop) page(offset int) (int, int, error) {
+	l.p.Offset = offset
+	n, r, err := l.sess.LikesGetList(&l.p)
+	if err != nil {
+		return 0, 0, err
+	}
+	l.items = r
+	return len(r), n, nil
+}
+
+func (l *likerLoop) More() (*Liker, error) {
+	for len(l.items) == 0 {
+		if err := l.next(l.page); err != nil {
+			return nil, err
+		}
+	}
+	v := &l.items[0]
+	l.items = l.items[1:]
+	return v, nil
 }
@@ -0,0 +1,331 @@
+package vk
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ModAction is set of actions taken on the comment violating a rule.
+type ModAction int
+
+const (
+	ModDelete ModAction = 1 << iota
+	ModBan
+	ModReport
+)
+
+type (
+	// ModEvent is the comment checked by the moderation rules.
+	ModEvent struct {
+		Type    string // RT_WallReplyNew or RT_PhotoCommentNew
+		GroupId int
+		Comment *Comment
+		Time    time.Time
+	}
+
+	// Verdict is the decision of a rule on violating comment.
+	Verdict struct {
+		Rule    string
+		Actions ModAction
+		// BanFor is the ban period of ModBan, zero bans forever.
+		BanFor    time.Duration
+		BanReason int // one of Ban* constants
+		// ReportReason is one of Report* constants for ModReport
+		ReportReason int
+		Comment      string // shown to the banned user
+	}
+
+	// ModRule checks comment and returns nil Verdict when it passes.
+	ModRule interface {
+		Check(*ModEvent) *Verdict
+	}
+
+	// ModRuleFunc adapts function to ModRule.
+	ModRuleFunc func(*ModEvent) *Verdict
+)
+
+func (f ModRuleFunc) Check(e *ModEvent) *Verdict {
+	return f(e)
+}
+
+// RegexRule rejects comment which text matches Re.
+type RegexRule struct {
+	Re *regexp.Regexp
+	Verdict
+}
+
+func (r *RegexRule) Check(e *ModEvent) *Verdict {
+	if r.Re.MatchString(e.Comment.Text) {
+		return &r.Verdict
+	}
+	return nil
+}
+
+var linkRe = regexp.MustCompile(`(?i)(?:https?://)?((?:[a-z0-9-]+\.)+[a-z]{2,})(?:[/?#:]\S*)?`)
+
+// LinkDomainRule rejects comment linking to any of Domains or their sub
+// domains. When Allow is set Domains is the list of allowed domains instead
+// and any other link is rejected.
+type LinkDomainRule struct {
+	Domains []string
+	Allow   bool
+	Verdict
+}
+
+func matchDomain(host string, ds []string) bool {
+	host = strings.ToLower(host)
+	for _, d := range ds {
+		d = strings.ToLower(d)
+		if host == d || strings.HasSuffix(host, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *LinkDomainRule) Check(e *ModEvent) *Verdict {
+	for _, m := range linkRe.FindAllStringSubmatch(e.Comment.Text, -1) {
+		if matchDomain(m[1], r.Domains) != r.Allow {
+			return &r.Verdict
+		}
+	}
+	return nil
+}
+
+// AttachmentTypeRule rejects comment with attachment of any of Types such as
+// AT_Link or AT_Doc.
+type AttachmentTypeRule struct {
+	Types []string
+	Verdict
+}
+
+func (r *AttachmentTypeRule) Check(e *ModEvent) *Verdict {
+	for _, a := range e.Comment.Attachments {
+		if ElemInSlice(a.Type, r.Types) {
+			return &r.Verdict
+		}
+	}
+	return nil
+}
+
+// NewMemberRule rejects comment of user who joined the community less than
+// Period ago. Joins are learnt from RT_GroupJoin events passed to Moderator,
+// users joined before are not considered new.
+type NewMemberRule struct {
+	Period time.Duration
+	Verdict
+	joined map[int]time.Time
+	sync.Mutex
+}
+
+func (r *NewMemberRule) Joined(uid int, t time.Time) {
+	r.Lock()
+	if r.joined == nil {
+		r.joined = make(map[int]time.Time)
+	}
+	// forget the members who are not new any more
+	for id, jt := range r.joined {
+		if t.Sub(jt) >= r.Period {
+			delete(r.joined, id)
+		}
+	}
+	r.joined[uid] = t
+	r.Unlock()
+}
+
+func (r *NewMemberRule) Check(e *ModEvent) *Verdict {
+	r.Lock()
+	defer r.Unlock()
+	t, ok := r.joined[e.Comment.FromId]
+	if !ok {
+		return nil
+	}
+	if e.Time.Sub(t) < r.Period {
+		return &r.Verdict
+	}
+	delete(r.joined, e.Comment.FromId)
+	return nil
+}
+
+// RepeatRule rejects comment when the same user posted the same text more
+// than Max times within Window.
+type RepeatRule struct {
+	Window time.Duration
+	Max    int
+	Verdict
+	seen  map[string][]time.Time
+	swept time.Time
+	sync.Mutex
+}
+
+// sweep drops the keys which all times are out of the window at now.
+func (r *RepeatRule) sweep(now time.Time) {
+	for k, ts := range r.seen {
+		if now.Sub(ts[len(ts)-1]) >= r.Window {
+			delete(r.seen, k)
+		}
+	}
+	r.swept = now
+}
+
+func (r *RepeatRule) Check(e *ModEvent) *Verdict {
+	text := strings.ToLower(strings.TrimSpace(e.Comment.Text))
+	if text == "" {
+		return nil
+	}
+	k := fmt.Sprint(e.Comment.FromId, ":", text)
+	r.Lock()
+	defer r.Unlock()
+	if r.seen == nil {
+		r.seen = make(map[string][]time.Time)
+	}
+	if e.Time.Sub(r.swept) >= r.Window {
+		r.sweep(e.Time)
+	}
+	ts := r.seen[k][:0]
+	for _, t := range r.seen[k] {
+		if e.Time.Sub(t) < r.Window {
+			ts = append(ts, t)
+		}
+	}
+	ts = append(ts, e.Time)
+	r.seen[k] = ts
+	if len(ts) > r.Max {
+		return &r.Verdict
+	}
+	return nil
+}
+
+type memberObserver interface {
+	Joined(uid int, t time.Time)
+}
+
+// Moderator checks new wall and photo comments of a community against Rules
+// and takes the actions of the first violated rule. Session must be able to
+// moderate the community (admin user token for ban and report).
+type Moderator struct {
+	sess  *Session
+	gid   int
+	Rules []ModRule
+	// DryRun only reports the verdicts without taking actions.
+	DryRun bool
+	// OnVerdict is called for every violation, default prints it when
+	// Debug is set.
+	OnVerdict func(*ModEvent, *Verdict)
+}
+
+func NewModerator(s *Session, gid int, rules ...ModRule) *Moderator {
+	return &Moderator{sess: s, gid: gid, Rules: rules}
+}
+
+// Register makes d pass comment and join events to m. Handler already
+// registered for these events is kept and called after m.
+func (m *Moderator) Register(d *Dispatcher) *Dispatcher {
+	for _, t := range []string{RT_WallReplyNew, RT_PhotoCommentNew, RT_GroupJoin} {
+		prev := d.handlers[t]
+		d.Handle(t, func(rr *ReceivedResult) error {
+			if err := m.Handle(rr); err != nil {
+				return err
+			}
+			if prev != nil {
+				return prev(rr)
+			}
+			return nil
+		})
+	}
+	return d
+}
+
+// Handle is the Handler of RT_WallReplyNew, RT_PhotoCommentNew and
+// RT_GroupJoin events, other events are ignored.
+func (m *Moderator) Handle(rr *ReceivedResult) error {
+	now := time.Now()
+	switch rr.Type {
+	case RT_GroupJoin:
+		j, err := rr.GetGroupJoin()
+		if err != nil {
+			return err
+		}
+		uid, err := strconv.Atoi(j.UserId)
+		if err != nil {
+			return err
+		}
+		for _, r := range m.Rules {
+			if o, ok := r.(memberObserver); ok {
+				o.Joined(uid, now)
+			}
+		}
+		return nil
+	case RT_WallReplyNew, RT_PhotoCommentNew:
+	default:
+		return nil
+	}
+	c, err := rr.WallComment()
+	if err != nil {
+		return err
+	}
+	if c.FromId == -m.gid {
+		// community own comment
+		return nil
+	}
+	e := &ModEvent{Type: rr.Type, GroupId: rr.GroupId, Comment: c, Time: now}
+	for _, r := range m.Rules {
+		if v := r.Check(e); v != nil {
+			return m.apply(e, v)
+		}
+	}
+	return nil
+}
+
+func (m *Moderator) apply(e *ModEvent, v *Verdict) error {
+	if m.OnVerdict != nil {
+		m.OnVerdict(e, v)
+	} else if Debug {
+		fmt.Printf("vk moderator rule %s violated by comment %d of %d, dry run: %v\n",
+			v.Rule, e.Comment.Id, e.Comment.FromId, m.DryRun)
+	}
+	if m.DryRun {
+		return nil
+	}
+	c := e.Comment
+	owner := CommunityOwner(m.gid)
+	isPhoto := e.Type == RT_PhotoCommentNew
+	if v.Actions&ModReport != 0 {
+		var err error
+		if isPhoto {
+			err = m.sess.PhotosReportComment(owner, c.Id, v.ReportReason)
+		} else {
+			err = m.sess.WallReportComment(owner, c.Id, v.ReportReason)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if v.Actions&ModDelete != 0 {
+		var err error
+		if isPhoto {
+			err = m.sess.PhotosDeleteComment(owner, c.Id)
+		} else {
+			err = m.sess.WallDeleteComment(owner, c.Id)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if v.Actions&ModBan != 0 {
+		var end time.Time
+		if v.BanFor > 0 {
+			end = e.Time.Add(v.BanFor)
+		}
+		err := m.sess.GroupsBan(m.gid, Owner(c.FromId), end, v.BanReason,
+			v.Comment, v.Comment != "")
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
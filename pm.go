@@ -1,6 +1,9 @@
 package vk
 
 import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
 	"net/url"
 	"strconv"
 	"strings"
@@ -10,6 +13,10 @@ const (
 	MsgUnreadOnly  = 1
 	MsgNotChat     = 2
 	MsgFromFriends = 4
+
+	// ChatPeerOffset is added to chat ID to get its peer ID
+	ChatPeerOffset = 2000000000
+	maxSendUsers   = 100
 )
 
 // https://vk.com/dev/message
@@ -22,16 +29,26 @@ type (
 	Message struct {
 		Id          int           `json:"id"`
 		UserId      int           `json:"user_id"`
+		PeerId      int           `json:"peer_id"`
+		FromId      int           `json:"from_id"`
 		Date        int64         `json:"date"`
 		ReadState   Bool          `json:"read_state"`
 		Out         Bool          `json:"out"`
 		Title       string        `json:"title"`
 		Body        string        `json:"body"`
+		Text        string        `json:"text"` // body since API 5.80
 		Attachments []*Attachment `json:"attachments"`
 		Emoji       Bool          `json:"emoji"`
 		Deleted     Bool          `json:"deleted"`
 		Fwd         []*Message    `json:"fwd_messages"`
 		G           *Geo          `json:"geo"`
+		ConvMsgId   int           `json:"conversation_message_id"`
+		RandomId    int           `json:"random_id"`
+		UpdateTime  int64         `json:"update_time"`
+		Important   bool          `json:"important"`
+		Reply       *Message      `json:"reply_message"`
+		// keyboard button payload (JSON string)
+		Payload string `json:"payload"`
 		// for group chat
 		ChatId     int    `json:"chat_id"`
 		ChatActive []int  `json:"chat_active"`
@@ -76,3 +93,313 @@ func (s *Session) MsgsMarkAsRead(ids []int) (Bool, error) {
 	}
 	return b, nil
 }
+
+// MsgSendParams is the parameters of https://vk.com/dev/messages.send. Only
+// one of PeerId, UserId, ChatId, Domain or UserIds must be set.
+type MsgSendParams struct {
+	PeerId int
+	UserId int
+	ChatId int
+	Domain string
+	// UserIds sends to up to 100 users at once (community token only), see
+	// MsgsSendMulti.
+	UserIds []int
+
+	Message string
+	// Attachment is comma separated attachment strings such as the result
+	// of AttachmentUploader.Upload.
+	Attachment      string
+	ForwardMessages []int
+	ReplyTo         int
+	StickerId       int
+	Lat, Long       float64
+	Keyboard        *Keyboard
+	// RandomId makes sending idempotent: VK will not send again the message
+	// with same RandomId. Zero RandomId is generated by MsgsSend and stored
+	// back so the same params can be sent again safely.
+	RandomId int
+}
+
+func genRandomId() (int, error) {
+	var b [4]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return 0, err
+	}
+	return int(binary.LittleEndian.Uint32(b[:]) & 0x7fffffff), nil
+}
+
+func (p *MsgSendParams) values() (url.Values, error) {
+	vals := make(url.Values)
+	var n int
+	if p.PeerId != 0 {
+		vals.Set("peer_id", strconv.Itoa(p.PeerId))
+		n++
+	}
+	if p.UserId != 0 {
+		vals.Set("user_id", strconv.Itoa(p.UserId))
+		n++
+	}
+	if p.ChatId != 0 {
+		vals.Set("chat_id", strconv.Itoa(p.ChatId))
+		n++
+	}
+	if p.Domain != "" {
+		vals.Set("domain", p.Domain)
+		n++
+	}
+	if len(p.UserIds) > 0 {
+		if len(p.UserIds) > maxSendUsers {
+			return nil, errors.New("vk: messages.send is limited to 100 user ids")
+		}
+		vals.Set("user_ids", IdList(p.UserIds).String())
+		n++
+	}
+	if n != 1 {
+		return nil, errors.New("vk: exactly one of peer, user, chat, domain or user ids is required")
+	}
+	if p.Message == "" && p.Attachment == "" && p.StickerId == 0 &&
+		len(p.ForwardMessages) == 0 {
+		return nil, errors.New("vk: empty message")
+	}
+	if p.Message != "" {
+		vals.Set("message", p.Message)
+	}
+	if p.Attachment != "" {
+		vals.Set("attachment", p.Attachment)
+	}
+	if len(p.ForwardMessages) > 0 {
+		vals.Set("forward_messages", IdList(p.ForwardMessages).String())
+	}
+	if p.ReplyTo != 0 {
+		vals.Set("reply_to", strconv.Itoa(p.ReplyTo))
+	}
+	if p.StickerId != 0 {
+		vals.Set("sticker_id", strconv.Itoa(p.StickerId))
+	}
+	if p.Lat != 0 || p.Long != 0 {
+		vals.Set("lat", strconv.FormatFloat(p.Lat, 'f', -1, 64))
+		vals.Set("long", strconv.FormatFloat(p.Long, 'f', -1, 64))
+	}
+	if p.Keyboard != nil {
+		kb, err := p.Keyboard.JSON()
+		if err != nil {
+			return nil, err
+		}
+		vals.Set("keyboard", kb)
+	}
+	if p.RandomId == 0 {
+		id, err := genRandomId()
+		if err != nil {
+			return nil, err
+		}
+		p.RandomId = id
+	}
+	vals.Set("random_id", strconv.Itoa(p.RandomId))
+	return vals, nil
+}
+
+// MsgsSend implements method https://vk.com/dev/messages.send and returns the
+// sent message ID. The random_id is fixed before the call so the retries of
+// CallAPI never send duplicated messages.
+func (s *Session) MsgsSend(p *MsgSendParams) (int, error) {
+	if len(p.UserIds) > 0 {
+		return 0, errors.New("vk: use MsgsSendMulti to send to user ids")
+	}
+	vals, err := p.values()
+	if err != nil {
+		return 0, err
+	}
+	var id int
+	if err = s.CallAPI("messages.send", vals, &id); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// MsgEditParams is the parameters of https://vk.com/dev/messages.edit.
+// Message and Attachment replace the current ones.
+type MsgEditParams struct {
+	PeerId     int
+	MessageId  int
+	Message    string
+	Attachment string
+	Lat, Long  float64
+	// KeepFwd keeps the forwarded messages and KeepSnippets keeps the link
+	// snippets of the original message.
+	KeepFwd      bool
+	KeepSnippets bool
+	Keyboard     *Keyboard
+}
+
+// MsgsEdit implements method https://vk.com/dev/messages.edit
+func (s *Session) MsgsEdit(p *MsgEditParams) error {
+	if p.PeerId == 0 || p.MessageId == 0 {
+		return errors.New("vk: peer id and message id are required")
+	}
+	vals := make(url.Values)
+	vals.Set("peer_id", strconv.Itoa(p.PeerId))
+	vals.Set("message_id", strconv.Itoa(p.MessageId))
+	if p.Message != "" {
+		vals.Set("message", p.Message)
+	}
+	if p.Attachment != "" {
+		vals.Set("attachment", p.Attachment)
+	}
+	if p.Lat != 0 || p.Long != 0 {
+		vals.Set("lat", strconv.FormatFloat(p.Lat, 'f', -1, 64))
+		vals.Set("long", strconv.FormatFloat(p.Long, 'f', -1, 64))
+	}
+	if p.KeepFwd {
+		vals.Set("keep_forward_messages", "1")
+	}
+	if p.KeepSnippets {
+		vals.Set("keep_snippets", "1")
+	}
+	if p.Keyboard != nil {
+		kb, err := p.Keyboard.JSON()
+		if err != nil {
+			return err
+		}
+		vals.Set("keyboard", kb)
+	}
+	var b Bool
+	return s.CallAPI("messages.edit", vals, &b)
+}
+
+// MsgsDelete implements method https://vk.com/dev/messages.delete. spam marks
+// the messages as spam and forAll deletes them for all the recipients (only
+// within 24 hours after sending). It returns the result of each message ID.
+func (s *Session) MsgsDelete(ids []int, spam, forAll bool) (map[int]bool, error) {
+	if len(ids) == 0 {
+		return nil, errors.New("you must pass at least one message id")
+	}
+	vals := make(url.Values)
+	vals.Set("message_ids", IdList(ids).String())
+	if spam {
+		vals.Set("spam", "1")
+	}
+	if forAll {
+		vals.Set("delete_for_all", "1")
+	}
+	var r map[string]Bool
+	if err := s.CallAPI("messages.delete", vals, &r); err != nil {
+		return nil, err
+	}
+	res := make(map[int]bool, len(r))
+	for k, v := range r {
+		id, err := strconv.Atoi(k)
+		if err != nil {
+			return nil, err
+		}
+		res[id] = bool(v)
+	}
+	return res, nil
+}
+
+// MsgsRestore implements method https://vk.com/dev/messages.restore
+func (s *Session) MsgsRestore(id int) error {
+	vals := make(url.Values)
+	vals.Set("message_id", strconv.Itoa(id))
+	var b Bool
+	return s.CallAPI("messages.restore", vals, &b)
+}
+
+// MsgsPin implements method https://vk.com/dev/messages.pin and returns the
+// pinned message.
+func (s *Session) MsgsPin(peerId, id int) (*Message, error) {
+	vals := make(url.Values)
+	vals.Set("peer_id", strconv.Itoa(peerId))
+	vals.Set("message_id", strconv.Itoa(id))
+	var m Message
+	if err := s.CallAPI("messages.pin", vals, &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// MsgsUnpin implements method https://vk.com/dev/messages.unpin
+func (s *Session) MsgsUnpin(peerId int) error {
+	vals := make(url.Values)
+	vals.Set("peer_id", strconv.Itoa(peerId))
+	var b Bool
+	return s.CallAPI("messages.unpin", vals, &b)
+}
+
+// MsgsMarkAsImportant implements method
+// https://vk.com/dev/messages.markAsImportant and returns the IDs of the
+// changed messages.
+func (s *Session) MsgsMarkAsImportant(ids []int, important bool) ([]int, error) {
+	if len(ids) == 0 {
+		return nil, errors.New("you must pass at least one message id")
+	}
+	vals := make(url.Values)
+	vals.Set("message_ids", IdList(ids).String())
+	if important {
+		vals.Set("important", "1")
+	} else {
+		vals.Set("important", "0")
+	}
+	var r []int
+	if err := s.CallAPI("messages.markAsImportant", vals, &r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// MsgsDeleteConversation implements method
+// https://vk.com/dev/messages.deleteConversation and returns the ID of the
+// last deleted message.
+func (s *Session) MsgsDeleteConversation(peerId int) (int, error) {
+	vals := make(url.Values)
+	vals.Set("peer_id", strconv.Itoa(peerId))
+	var r struct {
+		LastDeletedId int `json:"last_deleted_id"`
+	}
+	if err := s.CallAPI("messages.deleteConversation", vals, &r); err != nil {
+		return 0, err
+	}
+	return r.LastDeletedId, nil
+}
+
+// MsgSendResult is the result of each recipient of MsgsSendMulti. Error is
+// nil when the message is sent.
+type MsgSendResult struct {
+	PeerId    int `json:"peer_id"`
+	MessageId int `json:"message_id"`
+	Error     *struct {
+		Code int    `json:"code"`
+		Desc string `json:"description"`
+	} `json:"error"`
+}
+
+// MsgsSendMulti implements method https://vk.com/dev/messages.send with
+// p.UserIds and returns the result of every recipient.
+func (s *Session) MsgsSendMulti(p *MsgSendParams) ([]*MsgSendResult, error) {
+	if len(p.UserIds) == 0 {
+		return nil, errors.New("you must pass at least one user id")
+	}
+	vals, err := p.values()
+	if err != nil {
+		return nil, err
+	}
+	var r []*MsgSendResult
+	if err = s.CallAPI("messages.send", vals, &r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// MsgsIsMessagesFromGroupAllowed implements method
+// https://vk.com/dev/messages.isMessagesFromGroupAllowed
+func (s *Session) MsgsIsMessagesFromGroupAllowed(gid, uid int) (bool, error) {
+	vals := make(url.Values)
+	vals.Set("group_id", strconv.Itoa(gid))
+	vals.Set("user_id", strconv.Itoa(uid))
+	var r struct {
+		IsAllowed Bool `json:"is_allowed"`
+	}
+	if err := s.CallAPI("messages.isMessagesFromGroupAllowed", vals, &r); err != nil {
+		return false, err
+	}
+	return bool(r.IsAllowed), nil
+}
@@ -0,0 +1,229 @@
+package vk
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	maxPollAnswers = 10
+	maxPollVoters  = 1000
+)
+
+type (
+	// PollParams is the parameters of https://vk.com/dev/polls.create.
+	// Zero EndDate creates poll without time limit.
+	PollParams struct {
+		Owner     Owner
+		Question  string
+		Answers   []string
+		Anonymous bool
+		Multiple  bool
+		EndDate   time.Time
+	}
+
+	// PollVoters is the voters of one answer returned by polls.getVoters.
+	// Users only have Id unless fields are requested.
+	PollVoters struct {
+		AnswerId int
+		Count    int
+		Users    []User
+	}
+
+	// PollVotersParams is the parameters of
+	// https://vk.com/dev/polls.getVoters. Zero Count uses VK default (100).
+	PollVotersParams struct {
+		Owner       Owner
+		PollId      int
+		AnswerIds   []int
+		IsBoard     bool
+		FriendsOnly bool
+		Offset      int
+		Count       int
+		Fields      []string
+		NameCase    string
+	}
+)
+
+// String returns the attachment string of the poll such as "poll-1_2" that
+// can be used as WallPostParams.Attachments.
+func (p *Poll) String() string {
+	return attachString(AT_Poll, p.OwnerId, p.Id, "")
+}
+
+// PollsCreate implements method https://vk.com/dev/polls.create
+func (s *Session) PollsCreate(p *PollParams) (*Poll, error) {
+	if p.Question == "" {
+		return nil, errors.New("vk: empty poll question")
+	}
+	if n := len(p.Answers); n == 0 || n > maxPollAnswers {
+		return nil, errors.New(fmt.Sprint("vk: invalid number of poll answers: ", n))
+	}
+	b, err := json.Marshal(p.Answers)
+	if err != nil {
+		return nil, err
+	}
+	vals := make(url.Values)
+	vals.Set("question", p.Question)
+	vals.Set("add_answers", string(b))
+	p.Owner.set(vals)
+	boolVal(vals, "is_anonymous", p.Anonymous)
+	boolVal(vals, "is_multiple", p.Multiple)
+	if !p.EndDate.IsZero() {
+		vals.Set("end_date", strconv.FormatInt(p.EndDate.Unix(), 10))
+	}
+	var poll Poll
+	if err := s.CallAPI("polls.create", vals, &poll); err != nil {
+		return nil, err
+	}
+	return &poll, nil
+}
+
+// PollsGetById implements method https://vk.com/dev/polls.getById. isBoard
+// is true if the poll is attached to discussion board.
+func (s *Session) PollsGetById(o Owner, id int, isBoard bool) (*Poll, error) {
+	vals := make(url.Values)
+	o.set(vals)
+	vals.Set("poll_id", strconv.Itoa(id))
+	boolVal(vals, "is_board", isBoard)
+	var poll Poll
+	if err := s.CallAPI("polls.getById", vals, &poll); err != nil {
+		return nil, err
+	}
+	return &poll, nil
+}
+
+// PollsAddVote implements method https://vk.com/dev/polls.addVote. More
+// than one answer is only allowed for multiple choice poll.
+func (s *Session) PollsAddVote(o Owner, id int, answerIds []int,
+	isBoard bool) error {
+	if len(answerIds) == 0 {
+		return errors.New("vk: no poll answer to vote")
+	}
+	vals := make(url.Values)
+	o.set(vals)
+	vals.Set("poll_id", strconv.Itoa(id))
+	vals.Set("answer_ids", IdList(answerIds).String())
+	boolVal(vals, "is_board", isBoard)
+	var b Bool
+	return s.CallAPI("polls.addVote", vals, &b)
+}
+
+// PollsDeleteVote implements method https://vk.com/dev/polls.deleteVote
+func (s *Session) PollsDeleteVote(o Owner, id, answerId int,
+	isBoard bool) error {
+	vals := make(url.Values)
+	o.set(vals)
+	vals.Set("poll_id", strconv.Itoa(id))
+	vals.Set("answer_id", strconv.Itoa(answerId))
+	boolVal(vals, "is_board", isBoard)
+	var b Bool
+	return s.CallAPI("polls.deleteVote", vals, &b)
+}
+
+// PollsGetVoters implements method https://vk.com/dev/polls.getVoters.
+// Offset and Count apply to the voters of each answer.
+func (s *Session) PollsGetVoters(p *PollVotersParams) ([]PollVoters, error) {
+	if len(p.AnswerIds) == 0 {
+		return nil, errors.New("vk: no poll answer ids")
+	}
+	if p.Count > maxPollVoters {
+		return nil, errors.New("vk: voters count is limited to 1000")
+	}
+	vals := make(url.Values)
+	p.Owner.set(vals)
+	vals.Set("poll_id", strconv.Itoa(p.PollId))
+	vals.Set("answer_ids", IdList(p.AnswerIds).String())
+	boolVal(vals, "is_board", p.IsBoard)
+	boolVal(vals, "friends_only", p.FriendsOnly)
+	if p.Offset > 0 {
+		vals.Set("offset", strconv.Itoa(p.Offset))
+	}
+	if p.Count > 0 {
+		vals.Set("count", strconv.Itoa(p.Count))
+	}
+	setExtended(vals, false, p.Fields)
+	if p.NameCase != "" {
+		vals.Set("name_case", p.NameCase)
+	}
+	var r []struct {
+		AnswerId int `json:"answer_id"`
+		Users    struct {
+			Count int               `json:"count"`
+			Items []json.RawMessage `json:"items"`
+		} `json:"users"`
+	}
+	if err := s.CallAPI("polls.getVoters", vals, &r); err != nil {
+		return nil, err
+	}
+	vs := make([]PollVoters, 0, len(r))
+	for _, a := range r {
+		v := PollVoters{
+			AnswerId: a.AnswerId,
+			Count:    a.Users.Count,
+			Users:    make([]User, len(a.Users.Items)),
+		}
+		for i, b := range a.Users.Items {
+			// items are plain user ids when no fields requested
+			var err error
+			if len(b) > 0 && b[0] == '{' {
+				err = json.Unmarshal(b, &v.Users[i])
+			} else {
+				err = json.Unmarshal(b, &v.Users[i].Id)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		vs = append(vs, v)
+	}
+	return vs, nil
+}
+
+type VoterLooper interface {
+	More() (*User, error)
+	Size() int
+}
+
+type voterLoop struct {
+	pager
+	sess  *Session
+	p     PollVotersParams
+	items []User
+}
+
+// NewPollVotersLoop creates VoterLooper over all the voters of the answer
+// starting at p.Offset. p.AnswerIds is replaced by answerId.
+func NewPollVotersLoop(s *Session, p *PollVotersParams,
+	answerId int) VoterLooper {
+	l := &voterLoop{sess: s, p: *p}
+	l.p.AnswerIds = []int{answerId}
+	l.p.Count = maxPollVoters
+	l.size, l.offset = maxPollVoters, p.Offset
+	return l
+}
+
+func (l *voterLoop) page(offset int) (int, int, error) {
+	l.p.Offset = offset
+	r, err := l.sess.PollsGetVoters(&l.p)
+	if err != nil || len(r) == 0 {
+		return 0, 0, err
+	}
+	l.items = r[0].Users
+	return len(r[0].Users), r[0].Count, nil
+}
+
+func (l *voterLoop) More() (*User, error) {
+	for len(l.items) == 0 {
+		if err := l.next(l.page); err != nil {
+			return nil, err
+		}
+	}
+	u := &l.items[0]
+	l.items = l.items[1:]
+	return u, nil
+}
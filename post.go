@@ -2,18 +2,34 @@ package vk
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/url"
 	"strconv"
+	"strings"
+	"time"
 )
 
 const (
-	PostSrc_VK       = "vk"
-	PostSrc_Widget   = "widget"
-	PostSrc_Api      = "api"
-	PostSrc_Rss      = "rss"
-	PostSrc_Sms      = "sms"
-	PostType_Post    = "post"
-	PostType_Suggest = "suggest"
+	PostSrc_VK        = "vk"
+	PostSrc_Widget    = "widget"
+	PostSrc_Api       = "api"
+	PostSrc_Rss       = "rss"
+	PostSrc_Sms       = "sms"
+	PostType_Post     = "post"
+	PostType_Suggest  = "suggest"
+	PostType_Postpone = "postpone"
+
+	// wall.get filter
+	WallFilterOwner     = "owner"
+	WallFilterOthers    = "others"
+	WallFilterAll       = "all"
+	WallFilterPostponed = "postponed"
+	WallFilterSuggests  = "suggests"
+
+	maxWallCount        = 100
+	maxCopyHistoryDepth = 10
 )
 
 type (
@@ -34,6 +50,9 @@ type (
 		Comments     struct {
 			Count   int  `json:"count"`
 			CanPost Bool `json:"can_post"`
+			// CanOpen is set for the closed comments of editable post
+			CanClose Bool `json:"can_close"`
+			CanOpen  Bool `json:"can_open"`
 		} `json:"comments"`
 		Likes struct {
 			Count      int  `json:"count"`
@@ -55,6 +74,16 @@ type (
 		CopyHistory []Post        `json:"copy_history"`
 		CanPin      Bool          `json:"can_pin"`
 		IsPinned    Bool          `json:"is_pinned"`
+		MarkedAsAds Bool          `json:"marked_as_ads"`
+	}
+
+	// Posts is returned by wall.get. Profiles and Groups are only filled
+	// when extended.
+	Posts struct {
+		Count    int     `json:"count"`
+		Items    []*Post `json:"items"`
+		Profiles []User  `json:"profiles"`
+		Groups   []Group `json:"groups"`
 	}
 
 	Geo struct {
@@ -89,6 +118,21 @@ type (
 		} `json:"likes"`
 		PostOwnerId int `json:"post_owner_id"`
 		PostId      int `json:"post_id"`
+		// since API 5.91
+		OwnerId        int   `json:"owner_id"`
+		ReplyToUser    int   `json:"reply_to_user"`
+		ReplyToComment int   `json:"reply_to_comment"`
+		ParentsStack   []int `json:"parents_stack"`
+		Deleted        bool  `json:"deleted"`
+		// photo_comment_new callback
+		PhotoId      int `json:"photo_id"`
+		PhotoOwnerId int `json:"photo_owner_id"`
+		Thread       *struct {
+			Count           int        `json:"count"`
+			Items           []*Comment `json:"items"`
+			CanPost         bool       `json:"can_post"`
+			ShowReplyButton bool       `json:"show_reply_button"`
+		} `json:"thread"`
 	}
 
 	// https://vk.com/dev/notifications.get
@@ -106,69 +150,465 @@ type (
 	}
 )
 
-// Post wall to either user's wall or user's page (community) wall. Current arg
-// wallOwnerOptions consist of 'idInt int' and 'isCommunity bool' options.
-// idInt is the user ID or community ID. isCommunity is the flag to indicate if
-// the idInt refering a normal user ID or community ID. m is the message that
-// want to be posted on the wall. If wallOwnerOptions is not supplied then this
-// will assume the current user owner of the session token. So, a group token
-// will return error as only user token is valid for wall posting action.
-func (s *Session) WallPost(m, a string, ownerOpts ...interface{}) (int, error) {
+// WallPostParams is the parameters of https://vk.com/dev/wall.post and
+// https://vk.com/dev/wall.edit. Zero Owner posts to the wall of the current
+// user, so a group token must set Owner as only user token can post to its
+// own wall.
+type WallPostParams struct {
+	Owner   Owner
+	Message string
+	// Attachments is comma separated attachment strings such as the result
+	// of AttachmentUploader.Upload.
+	Attachments string
+	// FromGroup posts on behalf of the community Owner and Signed adds the
+	// author signature to it. FromGroup is ignored by wall.edit.
+	FromGroup bool
+	Signed    bool
+	// PublishDate postpones the post till the time
+	PublishDate time.Time
+	// FriendsOnly is only for user wall
+	FriendsOnly bool
+	// MarkAsAds is only for community wall
+	MarkAsAds     bool
+	CloseComments bool
+	// Services to export the post to, such as "twitter" or "facebook"
+	Services  []string
+	Lat, Long float64
+	// Guid prevents posting the same post twice. Ignored by wall.edit.
+	Guid string
+}
+
+func boolVal(vals url.Values, k string, b bool) {
+	if b {
+		vals.Set(k, "1")
+	}
+}
+
+func (p *WallPostParams) values(edit bool) (url.Values, error) {
+	if p.Message == "" && p.Attachments == "" {
+		return nil, errors.New("vk: either message or attachments is required")
+	}
+	if p.FromGroup && !p.Owner.IsCommunity() {
+		return nil, errors.New("vk: from group requires community owner")
+	}
+	if p.Signed && !p.FromGroup {
+		return nil, errors.New("vk: signed requires from group")
+	}
+	if p.MarkAsAds && !p.Owner.IsCommunity() {
+		return nil, errors.New("vk: mark as ads requires community owner")
+	}
+	if p.FriendsOnly && p.Owner.IsCommunity() {
+		return nil, errors.New("vk: friends only is not for community wall")
+	}
 	vals := make(url.Values)
-	vals.Set("message", m)
-	if ownerOptions(vals, ownerOpts...) {
-		vals.Set("from_group", "1")
+	p.Owner.set(vals)
+	if p.Message != "" {
+		vals.Set("message", p.Message)
+	}
+	if p.Attachments != "" {
+		vals.Set("attachments", p.Attachments)
+	}
+	if !edit {
+		boolVal(vals, "from_group", p.FromGroup)
+		if p.Guid != "" {
+			vals.Set("guid", p.Guid)
+		}
 	}
-	if a != "" {
-		vals.Set("attachments", a)
+	boolVal(vals, "signed", p.Signed)
+	boolVal(vals, "friends_only", p.FriendsOnly)
+	boolVal(vals, "mark_as_ads", p.MarkAsAds)
+	boolVal(vals, "close_comments", p.CloseComments)
+	if !p.PublishDate.IsZero() {
+		vals.Set("publish_date", strconv.FormatInt(p.PublishDate.Unix(), 10))
+	}
+	if len(p.Services) > 0 {
+		vals.Set("services", strings.Join(p.Services, ","))
+	}
+	if p.Lat != 0 || p.Long != 0 {
+		vals.Set("lat", strconv.FormatFloat(p.Lat, 'f', -1, 64))
+		vals.Set("long", strconv.FormatFloat(p.Long, 'f', -1, 64))
+	}
+	return vals, nil
+}
+
+// WallPost posts p to either user's wall or community wall and returns the
+// post ID.
+func (s *Session) WallPost(p *WallPostParams) (int, error) {
+	vals, err := p.values(false)
+	if err != nil {
+		return 0, err
 	}
 	var n struct {
 		PostId int `json:"post_id"`
 	}
-	if err := s.CallAPI("wall.post", vals, &n); err != nil {
+	if err = s.CallAPI("wall.post", vals, &n); err != nil {
 		return 0, err
 	}
 	return n.PostId, nil
 }
 
-func (s *Session) WallPostEdit(id int, m, a string, ownerOpts ...interface{}) error {
-	vals := make(url.Values)
-	vals.Set("post_id", strconv.Itoa(id))
-	vals.Set("message", m)
-	ownerOptions(vals, ownerOpts...)
-	if a != "" {
-		vals.Set("attachments", a)
+// WallPostEdit replaces post id of p.Owner wall with p.
+func (s *Session) WallPostEdit(id int, p *WallPostParams) error {
+	vals, err := p.values(true)
+	if err != nil {
+		return err
 	}
+	vals.Set("post_id", strconv.Itoa(id))
 	var r json.RawMessage
-	if err := s.CallAPI("wall.edit", vals, &r); err != nil {
+	if err = s.CallAPI("wall.edit", vals, &r); err != nil {
 		return err
 	}
 	return nil
 }
 
-func wallPinDel(s *Session, act string, id int, ownerOpts ...interface{}) error {
+func wallPinDel(s *Session, act string, o Owner, id int) error {
 	var r json.RawMessage
 	vals := make(url.Values)
 	vals.Set("post_id", strconv.Itoa(id))
-	ownerOptions(vals, ownerOpts...)
+	o.set(vals)
 	if err := s.CallAPI(act, vals, &r); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (s *Session) WallPin(id int, ownerOpts ...interface{}) error {
-	return wallPinDel(s, "wall.pin", id, ownerOpts...)
+func (s *Session) WallPin(o Owner, id int) error {
+	return wallPinDel(s, "wall.pin", o, id)
+}
+
+func (s *Session) WallUnpin(o Owner, id int) error {
+	return wallPinDel(s, "wall.unpin", o, id)
+}
+
+func (s *Session) WallDelete(o Owner, id int) error {
+	return wallPinDel(s, "wall.delete", o, id)
+}
+
+func (s *Session) WallRestore(o Owner, id int) error {
+	return wallPinDel(s, "wall.restore", o, id)
+}
+
+// WallGetParams is the parameters of https://vk.com/dev/wall.get. Either
+// Owner or Domain (screen name) selects the wall, both zero is the current
+// user wall. Zero Count uses VK default (20).
+type WallGetParams struct {
+	Owner    Owner
+	Domain   string
+	Filter   string
+	Offset   int
+	Count    int
+	Extended bool
+	Fields   []string
+}
+
+// WallGet implements method https://vk.com/dev/wall.get
+func (s *Session) WallGet(p *WallGetParams) (*Posts, error) {
+	if p.Count > maxWallCount {
+		return nil, errors.New("vk: wall count is limited to 100")
+	}
+	vals := make(url.Values)
+	p.Owner.set(vals)
+	if p.Domain != "" {
+		vals.Set("domain", p.Domain)
+	}
+	if p.Filter != "" {
+		vals.Set("filter", p.Filter)
+	}
+	if p.Offset > 0 {
+		vals.Set("offset", strconv.Itoa(p.Offset))
+	}
+	if p.Count > 0 {
+		vals.Set("count", strconv.Itoa(p.Count))
+	}
+	setExtended(vals, p.Extended, p.Fields)
+	var r Posts
+	if err := s.CallAPI("wall.get", vals, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func wallGetFiltered(s *Session, o Owner, filter string, offset, count int) (*Posts, error) {
+	return s.WallGet(&WallGetParams{
+		Owner:  o,
+		Filter: filter,
+		Offset: offset,
+		Count:  count,
+	})
+}
+
+// WallGetPostponed returns the postponed posts of wall o. Post.Date is the
+// scheduled publish time. Only the wall editors can see them.
+func (s *Session) WallGetPostponed(o Owner, offset, count int) (*Posts, error) {
+	return wallGetFiltered(s, o, WallFilterPostponed, offset, count)
+}
+
+// WallGetSuggested returns the posts suggested to community wall o.
+func (s *Session) WallGetSuggested(o Owner, offset, count int) (*Posts, error) {
+	return wallGetFiltered(s, o, WallFilterSuggests, offset, count)
+}
+
+// WallPublish publishes postponed or suggested post id of wall o right now
+// and returns the published post ID.
+func (s *Session) WallPublish(o Owner, id int) (int, error) {
+	vals := make(url.Values)
+	o.set(vals)
+	vals.Set("post_id", strconv.Itoa(id))
+	var n struct {
+		PostId int `json:"post_id"`
+	}
+	if err := s.CallAPI("wall.post", vals, &n); err != nil {
+		return 0, err
+	}
+	return n.PostId, nil
+}
+
+// WallReschedule changes the publish date of postponed post p (as returned
+// by WallGetPostponed). wall.edit replaces the whole post, so p is sent again
+// with its text, attachments, signature, geo, comments state and ads mark.
+// It fails if any attachment of p can not be attached again (see
+// Attachment.String) rather than dropping it. Suggested post is scheduled by
+// wall.post publish_date instead, see WallSchedule.
+func (s *Session) WallReschedule(p *Post, t time.Time) error {
+	if p.PostType != PostType_Postpone {
+		return errors.New("vk: only postponed post can be rescheduled")
+	}
+	wp, err := postParams(p, t)
+	if err != nil {
+		return err
+	}
+	return s.WallPostEdit(p.Id, wp)
+}
+
+// WallSchedule schedules suggested post p (as returned by WallGetSuggested)
+// to be published at t and returns its postponed post ID.
+func (s *Session) WallSchedule(p *Post, t time.Time) (int, error) {
+	if p.PostType != PostType_Suggest {
+		return 0, errors.New("vk: only suggested post can be scheduled")
+	}
+	wp, err := postParams(p, t)
+	if err != nil {
+		return 0, err
+	}
+	// wall.post needs from_group with signed, wp.Guid is empty
+	vals, err := wp.values(false)
+	if err != nil {
+		return 0, err
+	}
+	vals.Set("post_id", strconv.Itoa(p.Id))
+	var n struct {
+		PostId int `json:"post_id"`
+	}
+	if err = s.CallAPI("wall.post", vals, &n); err != nil {
+		return 0, err
+	}
+	return n.PostId, nil
+}
+
+// postParams returns the parameters to post p again at t.
+func postParams(p *Post, t time.Time) (*WallPostParams, error) {
+	as := make([]string, 0, len(p.Attachments))
+	for _, a := range p.Attachments {
+		str := a.String()
+		if str == "" {
+			return nil, errors.New(fmt.Sprint("vk: can not attach ", a.Type,
+				" of post ", p.Id, " again"))
+		}
+		as = append(as, str)
+	}
+	o := Owner(p.OwnerId)
+	wp := &WallPostParams{
+		Owner:         o,
+		Message:       p.Text,
+		Attachments:   strings.Join(as, ","),
+		FromGroup:     o.IsCommunity(),
+		Signed:        o.IsCommunity() && p.SignerId != 0,
+		PublishDate:   t,
+		FriendsOnly:   p.FriendsOnly == 1 && !o.IsCommunity(),
+		MarkAsAds:     bool(p.MarkedAsAds) && o.IsCommunity(),
+		CloseComments: bool(p.Comments.CanOpen),
+	}
+	if p.G != nil && p.G.Coor != "" {
+		// coordinates is "latitude longitude"
+		if _, err := fmt.Sscan(p.G.Coor, &wp.Lat, &wp.Long); err != nil {
+			return nil, err
+		}
+	}
+	return wp, nil
+}
+
+// PostRef returns the "owner_postid" string of post id of wall o used by
+// WallGetById.
+func PostRef(o Owner, id int) string {
+	return fmt.Sprint(o.String(), "_", id)
+}
+
+// WallGetById implements method https://vk.com/dev/wall.getById. refs are
+// the "owner_postid" strings (see PostRef) and copyDepth is the depth of
+// CopyHistory to return.
+func (s *Session) WallGetById(refs []string, copyDepth int, extended bool,
+	fields []string) (*Posts, error) {
+	if len(refs) == 0 {
+		return nil, errors.New("you must pass at least one post")
+	}
+	vals := make(url.Values)
+	vals.Set("posts", strings.Join(refs, ","))
+	if copyDepth > 0 {
+		vals.Set("copy_history_depth", strconv.Itoa(copyDepth))
+	}
+	setExtended(vals, extended, fields)
+	var r Posts
+	if extended {
+		if err := s.CallAPI("wall.getById", vals, &r); err != nil {
+			return nil, err
+		}
+	} else {
+		if err := s.CallAPI("wall.getById", vals, &r.Items); err != nil {
+			return nil, err
+		}
+		r.Count = len(r.Items)
+	}
+	return &r, nil
+}
+
+// WallSearchParams is the parameters of https://vk.com/dev/wall.search.
+// Either Owner or Domain selects the wall.
+type WallSearchParams struct {
+	Owner      Owner
+	Domain     string
+	Query      string
+	OwnersOnly bool
+	Offset     int
+	Count      int
+	Extended   bool
+	Fields     []string
+}
+
+// WallSearch implements method https://vk.com/dev/wall.search
+func (s *Session) WallSearch(p *WallSearchParams) (*Posts, error) {
+	if p.Count > maxWallCount {
+		return nil, errors.New("vk: wall count is limited to 100")
+	}
+	vals := make(url.Values)
+	p.Owner.set(vals)
+	if p.Domain != "" {
+		vals.Set("domain", p.Domain)
+	}
+	vals.Set("query", p.Query)
+	boolVal(vals, "owners_only", p.OwnersOnly)
+	if p.Offset > 0 {
+		vals.Set("offset", strconv.Itoa(p.Offset))
+	}
+	if p.Count > 0 {
+		vals.Set("count", strconv.Itoa(p.Count))
+	}
+	setExtended(vals, p.Extended, p.Fields)
+	var r Posts
+	if err := s.CallAPI("wall.search", vals, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+type PostLooper interface {
+	More() (*Post, error)
+	Size() int
+}
+
+// pager is the offset paging state shared by the loopers. Embedding it
+// gives the looper its Size.
+type pager struct {
+	size   int // requested page size
+	offset int
+	count  int
+	done   bool
+}
+
+func (p *pager) Size() int {
+	return p.count
+}
+
+// next calls fetch for the page at offset, or returns io.EOF after the last
+// page. fetch stores the items in the looper and returns their number and the
+// total count, which is zero if unknown. fetch may move offset to where the
+// page really starts.
+func (p *pager) next(fetch func(offset int) (n, count int, err error)) error {
+	if p.done {
+		return io.EOF
+	}
+	n, count, err := fetch(p.offset)
+	if err != nil {
+		return err
+	}
+	p.count = count
+	p.offset += n
+	if n < p.size || (count > 0 && p.offset >= count) {
+		p.done = true
+	}
+	return nil
+}
+
+// postLoop pages by offset. New post published meanwhile shifts the pages,
+// so the posts already returned are skipped.
+type postLoop struct {
+	pager
+	fetch func(offset int) (*Posts, error)
+	items []*Post
+	seen  map[string]bool
 }
 
-func (s *Session) WallUnpin(id int, ownerOpts ...interface{}) error {
-	return wallPinDel(s, "wall.unpin", id, ownerOpts...)
+func (l *postLoop) page(offset int) (int, int, error) {
+	r, err := l.fetch(offset)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, p := range r.Items {
+		// post IDs are only unique within a wall
+		ref := PostRef(Owner(p.OwnerId), p.Id)
+		if !l.seen[ref] {
+			l.seen[ref] = true
+			l.items = append(l.items, p)
+		}
+	}
+	return len(r.Items), r.Count, nil
 }
 
-func (s *Session) WallDelete(id int, ownerOpts ...interface{}) error {
-	return wallPinDel(s, "wall.delete", id, ownerOpts...)
+func (l *postLoop) More() (*Post, error) {
+	for len(l.items) == 0 {
+		if err := l.next(l.page); err != nil {
+			return nil, err
+		}
+	}
+	p := l.items[0]
+	l.items = l.items[1:]
+	return p, nil
 }
 
-func (s *Session) WallRestore(id int, ownerOpts ...interface{}) error {
-	return wallPinDel(s, "wall.restore", id, ownerOpts...)
+// NewWallLoop creates PostLooper over the whole wall of p starting at
+// p.Offset. Size is known after the first More.
+func NewWallLoop(s *Session, p *WallGetParams) PostLooper {
+	wp := *p
+	wp.Count = maxWallCount
+	return &postLoop{
+		pager: pager{size: maxWallCount, offset: p.Offset},
+		seen:  make(map[string]bool),
+		fetch: func(offset int) (*Posts, error) {
+			wp.Offset = offset
+			return s.WallGet(&wp)
+		},
+	}
+}
+
+// NewWallSearchLoop creates PostLooper over all the results of p.
+func NewWallSearchLoop(s *Session, p *WallSearchParams) PostLooper {
+	sp := *p
+	sp.Count = maxWallCount
+	return &postLoop{
+		pager: pager{size: maxWallCount, offset: p.Offset},
+		seen:  make(map[string]bool),
+		fetch: func(offset int) (*Posts, error) {
+			sp.Offset = offset
+			return s.WallSearch(&sp)
+		},
+	}
 }
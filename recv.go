@@ -5,6 +5,34 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"strings"
+)
+
+const (
+	// received (callback) event type
+	RT_Confirmation     = "confirmation"
+	RT_MsgNew           = "message_new"
+	RT_MsgReply         = "message_reply"
+	RT_MsgEdit          = "message_edit"
+	RT_MsgEvent         = "message_event"
+	RT_PhotoNew         = "photo_new"
+	RT_PhotoCommentNew  = "photo_comment_new"
+	RT_AudioNew         = "audio_new"
+	RT_VideoNew         = "video_new"
+	RT_VideoCommentNew  = "video_comment_new"
+	RT_WallPostNew      = "wall_post_new"
+	RT_WallRepost       = "wall_repost"
+	RT_WallReplyNew     = "wall_reply_new"
+	RT_WallReplyEdit    = "wall_reply_edit"
+	RT_WallReplyRestore = "wall_reply_restore"
+	RT_WallReplyDelete  = "wall_reply_delete"
+	RT_BoardPostNew     = "board_post_new"
+	RT_BoardPostEdit    = "board_post_edit"
+	RT_BoardPostRestore = "board_post_restore"
+	RT_BoardPostDelete  = "board_post_delete"
+	RT_MarketCommentNew = "market_comment_new"
+	RT_GroupJoin        = "group_join"
+	RT_GroupLeave       = "group_leave"
 )
 
 const (
@@ -55,11 +83,26 @@ type (
 		} `json:"likes"`
 	}
 
+	// MsgEvent is the press of keyboard callback button
+	MsgEvent struct {
+		UserId                int             `json:"user_id"`
+		PeerId                int             `json:"peer_id"`
+		EventId               string          `json:"event_id"`
+		Payload               json.RawMessage `json:"payload"`
+		ConversationMessageId int             `json:"conversation_message_id"`
+	}
+
 	Receive struct {
 		secret string
 	}
 )
 
+// NewReceive creates Receive that rejects callback request which secret does
+// not match. Empty secret accepts all requests.
+func NewReceive(secret string) *Receive {
+	return &Receive{secret: secret}
+}
+
 // ParseRequest function
 func (rx *Receive) ParseRequest(r *http.Request) (res *ReceivedResult, err error) {
 	defer r.Body.Close()
@@ -124,7 +167,18 @@ func (rr *ReceivedResult) Video() (*Video, error) {
 	return v, nil
 }
 
+// PM decodes private message event. Since API 5.103 the message is wrapped
+// in object together with client_info, both formats are supported.
 func (rr *ReceivedResult) PM() (*Message, error) {
+	var w struct {
+		Message *Message `json:"message"`
+	}
+	if err := unmarshaler(&w, bytes.NewReader(rr.Object)); err != nil {
+		return nil, err
+	}
+	if w.Message != nil {
+		return w.Message, nil
+	}
 	v := &Message{}
 	if err := unmarshaler(v, bytes.NewReader(rr.Object)); err != nil {
 		return nil, err
@@ -132,6 +186,42 @@ func (rr *ReceivedResult) PM() (*Message, error) {
 	return v, nil
 }
 
+func (rr *ReceivedResult) MsgEvent() (*MsgEvent, error) {
+	v := &MsgEvent{}
+	if err := unmarshaler(v, bytes.NewReader(rr.Object)); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// VK sends user_id of group_join and group_leave as number while UserId is
+// string, so both are accepted.
+func (j *GroupJoin) UnmarshalJSON(b []byte) error {
+	type groupJoin GroupJoin
+	v := struct {
+		*groupJoin
+		UserId json.RawMessage `json:"user_id"`
+	}{groupJoin: (*groupJoin)(j)}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	j.UserId = strings.Trim(string(v.UserId), `"`)
+	return nil
+}
+
+func (l *GroupLeave) UnmarshalJSON(b []byte) error {
+	type groupLeave GroupLeave
+	v := struct {
+		*groupLeave
+		UserId json.RawMessage `json:"user_id"`
+	}{groupLeave: (*groupLeave)(l)}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	l.UserId = strings.Trim(string(v.UserId), `"`)
+	return nil
+}
+
 func (rr *ReceivedResult) GetGroupLeave() (*GroupLeave, error) {
 	v := &GroupLeave{}
 	if err := unmarshaler(v, bytes.NewReader(rr.Object)); err != nil {
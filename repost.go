@@ -0,0 +1,122 @@
+package vk
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// RepostResult is returned by wall.repost
+type RepostResult struct {
+	Success      Bool `json:"success"`
+	PostId       int  `json:"post_id"`
+	RepostsCount int  `json:"reposts_count"`
+	LikesCount   int  `json:"likes_count"`
+}
+
+// WallRef returns the "wall{owner}_{id}" string of post id of wall o. It is
+// the object of wall.repost and the attachment to share the post in message.
+func WallRef(o Owner, id int) string {
+	return fmt.Sprint("wall", o.String(), "_", id)
+}
+
+// WallRepost implements method https://vk.com/dev/wall.repost. It reposts
+// post id of wall o to the current user wall or, when gid is not zero, to
+// community gid wall.
+func (s *Session) WallRepost(o Owner, id int, m string, gid int, markAsAds bool) (*RepostResult, error) {
+	vals := make(url.Values)
+	vals.Set("object", WallRef(o, id))
+	if m != "" {
+		vals.Set("message", m)
+	}
+	if gid != 0 {
+		vals.Set("group_id", strconv.Itoa(gid))
+	}
+	boolVal(vals, "mark_as_ads", markAsAds)
+	var r RepostResult
+	if err := s.CallAPI("wall.repost", vals, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// RepostToMsg shares post id of wall o in private message p and returns the
+// message ID.
+func RepostToMsg(s *Session, o Owner, id int, p *MsgSendParams) (int, error) {
+	mp := *p
+	if mp.Attachment != "" {
+		mp.Attachment = fmt.Sprint(mp.Attachment, ",", WallRef(o, id))
+	} else {
+		mp.Attachment = WallRef(o, id)
+	}
+	mid, err := s.MsgsSend(&mp)
+	p.RandomId = mp.RandomId
+	return mid, err
+}
+
+// WallGetReposts implements method https://vk.com/dev/wall.getReposts. The
+// result has no Count, page till less than count items are returned.
+func (s *Session) WallGetReposts(o Owner, id, offset, count int) (*Posts, error) {
+	if count > maxWallCount {
+		return nil, errors.New("vk: reposts count is limited to 100")
+	}
+	vals := make(url.Values)
+	o.set(vals)
+	vals.Set("post_id", strconv.Itoa(id))
+	if offset > 0 {
+		vals.Set("offset", strconv.Itoa(offset))
+	}
+	if count > 0 {
+		vals.Set("count", strconv.Itoa(count))
+	}
+	var r Posts
+	if err := s.CallAPI("wall.getReposts", vals, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// NewRepostsLoop creates PostLooper over all the reposts of post id of wall
+// o. Size is always zero as VK does not return the reposts count here, see
+// Post.Reposts.
+func NewRepostsLoop(s *Session, o Owner, id int) PostLooper {
+	return &postLoop{
+		pager: pager{size: maxWallCount},
+		seen:  make(map[string]bool),
+		fetch: func(offset int) (*Posts, error) {
+			return s.WallGetReposts(o, id, offset, maxWallCount)
+		},
+	}
+}
+
+// Original returns the original post of repost p, which is the last of
+// CopyHistory, or p itself if it is not a repost.
+func (p *Post) Original() *Post {
+	if len(p.CopyHistory) == 0 {
+		return p
+	}
+	return &p.CopyHistory[len(p.CopyHistory)-1]
+}
+
+// ResolveOriginal fetches p with its full CopyHistory, which may be cut by
+// the copy_history_depth of the call p came from, and returns its original
+// post.
+func (s *Session) ResolveOriginal(p *Post) (*Post, error) {
+	if len(p.CopyHistory) == 0 {
+		return p, nil
+	}
+	owner := p.OwnerId
+	if owner == 0 {
+		owner = p.FromId
+	}
+	r, err := s.WallGetById([]string{PostRef(Owner(owner), p.Id)},
+		maxCopyHistoryDepth, false, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(r.Items) == 0 {
+		return nil, errors.New("vk: post not found")
+	}
+	return r.Items[0].Original(), nil
+}
@@ -0,0 +1,284 @@
+package vk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	defaultSchedLead     = time.Minute * 5
+	defaultSchedRetry    = time.Minute
+	defaultSchedAttempts = 5
+	schedTick            = time.Second * 10
+)
+
+// PlannedPost is a post kept by Scheduler. The file paths are uploaded
+// shortly before At and the post is published at At.
+type PlannedPost struct {
+	Id     string    `json:"id"`
+	Owner  Owner     `json:"owner"`
+	At     time.Time `json:"at"`
+	Text   string    `json:"text"`
+	Photos []string  `json:"photos,omitempty"`
+	Videos []string  `json:"videos,omitempty"`
+	Audios []string  `json:"audios,omitempty"`
+	Docs   []string  `json:"docs,omitempty"`
+	// FromGroup and Signed as in WallPostParams
+	FromGroup bool `json:"from_group,omitempty"`
+	Signed    bool `json:"signed,omitempty"`
+
+	// state
+	Attachments string    `json:"attachments,omitempty"`
+	Uploaded    bool      `json:"uploaded,omitempty"`
+	PostId      int       `json:"post_id,omitempty"`
+	Attempts    int       `json:"attempts,omitempty"`
+	NextTry     time.Time `json:"next_try,omitempty"`
+	LastErr     string    `json:"last_err,omitempty"`
+	Failed      bool      `json:"failed,omitempty"`
+}
+
+func (p *PlannedPost) hasFiles() bool {
+	return len(p.Photos)+len(p.Videos)+len(p.Audios)+len(p.Docs) > 0
+}
+
+// Done reports if p is published or has failed for good.
+func (p *PlannedPost) Done() bool {
+	return p.PostId != 0 || p.Failed
+}
+
+// Scheduler publishes planned posts through WallPost at their time. Unlike
+// VK postponed posts, the content can be generated by Prepare right before
+// the upload. The queue is kept in a JSON file so it survives restarts.
+// Session must be user token as it uploads the media with
+// NewAttachmentsUploader.
+type Scheduler struct {
+	sess  *Session
+	path  string
+	posts []*PlannedPost
+	// Lead is how long before At the media is uploaded.
+	Lead time.Duration
+	// RetryDelay and MaxAttempts control the retry of transient errors.
+	RetryDelay  time.Duration
+	MaxAttempts int
+	// Prepare is called before the upload to fill dynamic content. It is
+	// called again when the upload is retried.
+	Prepare func(*PlannedPost) error
+	sync.Mutex
+}
+
+// NewScheduler creates Scheduler keeping its queue in file path, loading the
+// existing queue if any.
+func NewScheduler(s *Session, path string) (*Scheduler, error) {
+	sc := &Scheduler{
+		sess:        s,
+		path:        path,
+		Lead:        defaultSchedLead,
+		RetryDelay:  defaultSchedRetry,
+		MaxAttempts: defaultSchedAttempts,
+	}
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return sc, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(b, &sc.posts); err != nil {
+		return nil, err
+	}
+	return sc, nil
+}
+
+// save writes the queue atomically. Caller must hold the lock.
+func (sc *Scheduler) save() error {
+	b, err := json.MarshalIndent(sc.posts, "", "\t")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(sc.path, b)
+}
+
+// Add queues p. Id must be unique within the queue.
+func (sc *Scheduler) Add(p *PlannedPost) error {
+	if p.Id == "" {
+		return errors.New("vk: planned post without id")
+	}
+	sc.Lock()
+	defer sc.Unlock()
+	for _, q := range sc.posts {
+		if q.Id == p.Id {
+			return errors.New("vk: planned post id exists: " + p.Id)
+		}
+	}
+	sc.posts = append(sc.posts, p)
+	return sc.save()
+}
+
+// Remove unqueues the post id.
+func (sc *Scheduler) Remove(id string) error {
+	sc.Lock()
+	defer sc.Unlock()
+	for i, q := range sc.posts {
+		if q.Id == id {
+			sc.posts = append(sc.posts[:i], sc.posts[i+1:]...)
+			return sc.save()
+		}
+	}
+	return errors.New("vk: planned post not found: " + id)
+}
+
+// List returns copy of all the queued posts including the done ones.
+func (sc *Scheduler) List() []PlannedPost {
+	sc.Lock()
+	defer sc.Unlock()
+	ps := make([]PlannedPost, len(sc.posts))
+	for i, p := range sc.posts {
+		ps[i] = *p
+	}
+	return ps
+}
+
+// isTransient reports if err may succeed when retried.
+func isTransient(err error) bool {
+	if e, ok := err.(*Error); ok {
+		return e.Code == ErrTooManyReq || e.Code == ErrInternalServer ||
+			e.Code == ErrUnknown
+	}
+	return true
+}
+
+func (sc *Scheduler) upload(p *PlannedPost) error {
+	if sc.Prepare != nil {
+		if err := sc.Prepare(p); err != nil {
+			return err
+		}
+	}
+	if !p.hasFiles() {
+		return nil
+	}
+	var gid int
+	if p.Owner.IsCommunity() {
+		// photos go to the community wall album
+		gid = p.Owner.Id()
+	}
+	up := NewAttachmentsUploader(sc.sess, gid)
+	up.AddPhotos(p.Photos)
+	up.AddAudios(p.Audios)
+	up.AddDocs(p.Docs)
+	for _, v := range p.Videos {
+		up.AddVideo(v, "")
+	}
+	a, err := up.Upload()
+	if err != nil {
+		return err
+	}
+	p.Attachments = a
+	return nil
+}
+
+func (sc *Scheduler) publish(p *PlannedPost) error {
+	id, err := sc.sess.WallPost(&WallPostParams{
+		Owner:       p.Owner,
+		Message:     p.Text,
+		Attachments: p.Attachments,
+		FromGroup:   p.FromGroup,
+		Signed:      p.Signed,
+		// avoid double post when the reply of a successful post is lost
+		Guid: p.Id,
+	})
+	if err != nil {
+		return err
+	}
+	p.PostId = id
+	return nil
+}
+
+// due reports if p is to be processed at now.
+func (sc *Scheduler) due(p *PlannedPost, now time.Time) bool {
+	return !p.Done() && !now.Before(p.NextTry) && !now.Before(p.At.Add(-sc.Lead))
+}
+
+// step processes due p at now and reports if p has changed.
+func (sc *Scheduler) step(p *PlannedPost, now time.Time) bool {
+	var err error
+	if !p.Uploaded {
+		if err = sc.upload(p); err == nil {
+			p.Uploaded = true
+			p.LastErr = ""
+			if now.Before(p.At) {
+				return true
+			}
+		}
+	} else if now.Before(p.At) {
+		// uploaded in advance and waiting
+		return false
+	}
+	if err == nil {
+		err = sc.publish(p)
+	}
+	if err == nil {
+		p.LastErr = ""
+		return true
+	}
+	p.Attempts++
+	p.LastErr = err.Error()
+	if !isTransient(err) || p.Attempts >= sc.MaxAttempts {
+		p.Failed = true
+	} else {
+		p.NextTry = now.Add(sc.RetryDelay)
+	}
+	return true
+}
+
+// Run processes the queue till ctx is done. Only queue saving error stops
+// it, failures of posts are recorded in their LastErr. The due posts are
+// processed on copies without holding the lock, so Add, Remove and List do
+// not wait for the uploads.
+func (sc *Scheduler) Run(ctx context.Context) error {
+	t := time.NewTicker(schedTick)
+	defer t.Stop()
+	for {
+		now := time.Now()
+		var due []PlannedPost
+		sc.Lock()
+		for _, p := range sc.posts {
+			if sc.due(p, now) {
+				due = append(due, *p)
+			}
+		}
+		sc.Unlock()
+		for i := range due {
+			p := &due[i]
+			if !sc.step(p, time.Now()) {
+				continue
+			}
+			// save every change so the upload is not repeated after restart
+			if err := sc.update(p); err != nil {
+				return err
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// update stores processed copy p back to the queue unless it has been
+// removed meanwhile.
+func (sc *Scheduler) update(p *PlannedPost) error {
+	sc.Lock()
+	defer sc.Unlock()
+	for _, q := range sc.posts {
+		if q.Id == p.Id {
+			*q = *p
+			return sc.save()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package vk
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	maxSearchMsgs  = 100
+	maxSearchConvs = 255
+)
+
+// MsgSearchParams is the parameters of https://vk.com/dev/messages.search.
+// Zero Count uses VK default (20).
+type MsgSearchParams struct {
+	Query  string
+	PeerId int
+	// Date limits the result to messages sent before it
+	Date          time.Time
+	PreviewLength int
+	Offset        int
+	Count         int
+	Extended      bool
+	Fields        []string
+}
+
+// MsgsSearch implements method https://vk.com/dev/messages.search
+func (s *Session) MsgsSearch(p *MsgSearchParams) (*MessagesExt, error) {
+	if p.Count > maxSearchMsgs {
+		return nil, errors.New("vk: search count is limited to 100")
+	}
+	vals := make(url.Values)
+	vals.Set("q", p.Query)
+	if p.PeerId != 0 {
+		vals.Set("peer_id", strconv.Itoa(p.PeerId))
+	}
+	if !p.Date.IsZero() {
+		vals.Set("date", p.Date.Format("02012006"))
+	}
+	if p.PreviewLength > 0 {
+		vals.Set("preview_length", strconv.Itoa(p.PreviewLength))
+	}
+	if p.Offset > 0 {
+		vals.Set("offset", strconv.Itoa(p.Offset))
+	}
+	if p.Count > 0 {
+		vals.Set("count", strconv.Itoa(p.Count))
+	}
+	setExtended(vals, p.Extended, p.Fields)
+
+	var m MessagesExt
+	if err := s.CallAPI("messages.search", vals, &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// MsgsSearchConversations implements method
+// https://vk.com/dev/messages.searchConversations
+func (s *Session) MsgsSearchConversations(q string, count int, extended bool,
+	fields []string) (*ConversationsById, error) {
+	if count > maxSearchConvs {
+		return nil, errors.New("vk: search conversations count is limited to 255")
+	}
+	vals := make(url.Values)
+	vals.Set("q", q)
+	if count > 0 {
+		vals.Set("count", strconv.Itoa(count))
+	}
+	setExtended(vals, extended, fields)
+
+	var c ConversationsById
+	if err := s.CallAPI("messages.searchConversations", vals, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+type MsgLooper interface {
+	More() (*Message, error)
+	Size() int
+}
+
+type searchLoop struct {
+	pager
+	sess  *Session
+	p     MsgSearchParams
+	items []*Message
+}
+
+// NewSearchLoop creates MsgLooper over all the results of p starting at
+// p.Offset. Size is known after the first More.
+func NewSearchLoop(s *Session, p *MsgSearchParams) MsgLooper {
+	l := &searchLoop{sess: s, p: *p}
+	if l.p.Count == 0 {
+		l.p.Count = maxSearchMsgs
+	}
+	l.size, l.offset = l.p.Count, p.Offset
+	return l
+}
+
+func (l *searchLoop) page(offset int) (int, int, error) {
+	l.p.Offset = offset
+	m, err := l.sess.MsgsSearch(&l.p)
+	if err != nil {
+		return 0, 0, err
+	}
+	l.items = m.Items
+	return len(m.Items), m.Count, nil
+}
+
+func (l *searchLoop) More() (*Message, error) {
+	for len(l.items) == 0 {
+		if err := l.next(l.page); err != nil {
+			return nil, err
+		}
+	}
+	m := l.items[0]
+	l.items = l.items[1:]
+	return m, nil
+}
@@ -1,6 +1,7 @@
 package vk
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/url"
@@ -67,3 +68,176 @@ func SetServer(s *Session, surl string, gid int) error {
 		return nil
 	})
 }
+
+const (
+	CBStatusUnconfigured = "unconfigured"
+	CBStatusFailed       = "failed"
+	CBStatusWait         = "wait"
+	CBStatusOk           = "ok"
+)
+
+// CBServer is a callback server returned by groups.getCallbackServers.
+type CBServer struct {
+	Id        int    `json:"id"`
+	Title     string `json:"title"`
+	CreatorId int    `json:"creator_id"`
+	Url       string `json:"url"`
+	SecretKey string `json:"secret_key"`
+	Status    string `json:"status"`
+}
+
+// GroupsGetCallbackConfirmationCode implements method
+// https://vk.com/dev/groups.getCallbackConfirmationCode
+func (s *Session) GroupsGetCallbackConfirmationCode(gid int) (string, error) {
+	v := url.Values{}
+	v.Set("group_id", strconv.Itoa(gid))
+	var c CBConfirmCode
+	if err := s.CallAPI("groups.getCallbackConfirmationCode", v, &c); err != nil {
+		return "", err
+	}
+	return c.Code, nil
+}
+
+func cbServerValues(gid int, title string, ss *CBServerSettings) url.Values {
+	v := url.Values{}
+	v.Set("group_id", strconv.Itoa(gid))
+	v.Set("url", ss.Url)
+	v.Set("title", title)
+	if ss.Key != "" {
+		v.Set("secret_key", ss.Key)
+	}
+	return v
+}
+
+// GroupsAddCallbackServer implements method
+// https://vk.com/dev/groups.addCallbackServer and returns the new server ID.
+// title is limited by VK to 14 characters.
+func (s *Session) GroupsAddCallbackServer(gid int, title string, ss *CBServerSettings) (int, error) {
+	var r struct {
+		ServerId int `json:"server_id"`
+	}
+	err := s.CallAPI("groups.addCallbackServer", cbServerValues(gid, title, ss), &r)
+	if err != nil {
+		return 0, err
+	}
+	return r.ServerId, nil
+}
+
+// GroupsEditCallbackServer implements method
+// https://vk.com/dev/groups.editCallbackServer
+func (s *Session) GroupsEditCallbackServer(gid, sid int, title string, ss *CBServerSettings) error {
+	v := cbServerValues(gid, title, ss)
+	v.Set("server_id", strconv.Itoa(sid))
+	var b Bool
+	return s.CallAPI("groups.editCallbackServer", v, &b)
+}
+
+// GroupsDeleteCallbackServer implements method
+// https://vk.com/dev/groups.deleteCallbackServer
+func (s *Session) GroupsDeleteCallbackServer(gid, sid int) error {
+	v := url.Values{}
+	v.Set("group_id", strconv.Itoa(gid))
+	v.Set("server_id", strconv.Itoa(sid))
+	var b Bool
+	return s.CallAPI("groups.deleteCallbackServer", v, &b)
+}
+
+// GroupsGetCallbackServers implements method
+// https://vk.com/dev/groups.getCallbackServers. Empty sids returns all the
+// servers of the community.
+func (s *Session) GroupsGetCallbackServers(gid int, sids ...int) ([]CBServer, error) {
+	v := url.Values{}
+	v.Set("group_id", strconv.Itoa(gid))
+	if len(sids) > 0 {
+		v.Set("server_ids", IdList(sids).String())
+	}
+	var servers []CBServer
+	list := ApiList{
+		Items: &servers,
+	}
+	if err := s.CallAPI("groups.getCallbackServers", v, &list); err != nil {
+		return nil, err
+	}
+	return servers, nil
+}
+
+// GroupsGetCallbackSettings implements method
+// https://vk.com/dev/groups.getCallbackSettings
+func (s *Session) GroupsGetCallbackSettings(gid, sid int) (*CBSettings, error) {
+	v := url.Values{}
+	v.Set("group_id", strconv.Itoa(gid))
+	if sid != 0 {
+		v.Set("server_id", strconv.Itoa(sid))
+	}
+	var r struct {
+		Events *CBSettings `json:"events"`
+	}
+	if err := s.CallAPI("groups.getCallbackSettings", v, &r); err != nil {
+		return nil, err
+	}
+	if r.Events == nil {
+		return nil, errors.New("vk: empty callback settings")
+	}
+	return r.Events, nil
+}
+
+// GroupsSetCallbackSettings implements method
+// https://vk.com/dev/groups.setCallbackSettings. Every event of cs is sent so
+// the disabled events are switched off too.
+func (s *Session) GroupsSetCallbackSettings(gid, sid int, cs *CBSettings) error {
+	b, err := json.Marshal(cs)
+	if err != nil {
+		return err
+	}
+	var events map[string]json.RawMessage
+	if err = json.Unmarshal(b, &events); err != nil {
+		return err
+	}
+	v := url.Values{}
+	v.Set("group_id", strconv.Itoa(gid))
+	if sid != 0 {
+		v.Set("server_id", strconv.Itoa(sid))
+	}
+	for k, e := range events {
+		v.Set(k, string(e))
+	}
+	var r Bool
+	return s.CallAPI("groups.setCallbackSettings", v, &r)
+}
+
+// EnsureServer makes sure the community gid has a callback server pointing to
+// ss.Url with secret ss.Key and title, and that its events match cs. Existing
+// server with the same URL is reused and only edited when its title or secret
+// differ, so calling it at every start up is safe. It returns the server ID.
+func EnsureServer(s *Session, gid int, title string, ss *CBServerSettings,
+	cs *CBSettings) (int, error) {
+	servers, err := s.GroupsGetCallbackServers(gid)
+	if err != nil {
+		return 0, err
+	}
+	var sid int
+	for _, sv := range servers {
+		if sv.Url != ss.Url {
+			continue
+		}
+		sid = sv.Id
+		if sv.Title != title || sv.SecretKey != ss.Key {
+			err = s.GroupsEditCallbackServer(gid, sid, title, ss)
+			if err != nil {
+				return 0, err
+			}
+		}
+		break
+	}
+	if sid == 0 {
+		if sid, err = s.GroupsAddCallbackServer(gid, title, ss); err != nil {
+			return 0, err
+		}
+	}
+	if cs != nil {
+		if err = s.GroupsSetCallbackSettings(gid, sid, cs); err != nil {
+			return 0, err
+		}
+	}
+	return sid, nil
+}
@@ -0,0 +1,132 @@
+package vk
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// Simulator builds callback API payloads for local development and testing
+// of callback handlers without waiting for real events from VK.
+type Simulator struct {
+	GroupId int
+	Secret  string
+	// URL is the local callback handler address used by Request and Send.
+	URL    string
+	Client *http.Client
+}
+
+// NewSimulator creates Simulator for community gid with callback secret and
+// the handler address surl.
+func NewSimulator(gid int, secret, surl string) *Simulator {
+	return &Simulator{
+		GroupId: gid,
+		Secret:  secret,
+		URL:     surl,
+		Client:  http.DefaultClient,
+	}
+}
+
+// Event creates ReceivedResult of type t with obj encoded as its object.
+func (sm *Simulator) Event(t string, obj interface{}) (*ReceivedResult, error) {
+	rr := &ReceivedResult{
+		Type:    t,
+		GroupId: sm.GroupId,
+		Secret:  sm.Secret,
+	}
+	if obj != nil {
+		b, err := json.Marshal(obj)
+		if err != nil {
+			return nil, err
+		}
+		rr.Object = b
+	}
+	return rr, nil
+}
+
+func (sm *Simulator) Confirmation() (*ReceivedResult, error) {
+	return sm.Event(RT_Confirmation, nil)
+}
+
+func (sm *Simulator) MsgNew(m *Message) (*ReceivedResult, error) {
+	return sm.Event(RT_MsgNew, m)
+}
+
+func (sm *Simulator) WallPostNew(p *Post) (*ReceivedResult, error) {
+	return sm.Event(RT_WallPostNew, p)
+}
+
+func (sm *Simulator) WallReplyNew(c *Comment) (*ReceivedResult, error) {
+	return sm.Event(RT_WallReplyNew, c)
+}
+
+func (sm *Simulator) PhotoCommentNew(c *Comment) (*ReceivedResult, error) {
+	return sm.Event(RT_PhotoCommentNew, c)
+}
+
+func (sm *Simulator) BoardPostNew(c *TopicComment) (*ReceivedResult, error) {
+	return sm.Event(RT_BoardPostNew, c)
+}
+
+func (sm *Simulator) BoardPostDelete(d *TopicDelete) (*ReceivedResult, error) {
+	return sm.Event(RT_BoardPostDelete, d)
+}
+
+func (sm *Simulator) GroupJoin(j *GroupJoin) (*ReceivedResult, error) {
+	return sm.Event(RT_GroupJoin, j)
+}
+
+func (sm *Simulator) GroupLeave(l *GroupLeave) (*ReceivedResult, error) {
+	return sm.Event(RT_GroupLeave, l)
+}
+
+// Request creates the POST request VK would send for rr. It can be passed
+// directly to http.Handler in unit tests.
+func (sm *Simulator) Request(rr *ReceivedResult) (*http.Request, error) {
+	b, err := json.Marshal(rr)
+	if err != nil {
+		return nil, err
+	}
+	surl := sm.URL
+	if surl == "" {
+		surl = "http://localhost/"
+	}
+	req, err := http.NewRequest("POST", surl, bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
+
+// Send posts rr to URL and returns the handler reply. Like VK, any reply
+// other than "ok" is an error except for confirmation event which reply is
+// the confirmation code.
+func (sm *Simulator) Send(rr *ReceivedResult) (string, error) {
+	req, err := sm.Request(rr)
+	if err != nil {
+		return "", err
+	}
+	resp, err := sm.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	reply := strings.TrimSpace(string(b))
+	if resp.StatusCode != http.StatusOK {
+		return reply, errors.New(fmt.Sprint("vk: callback handler status: ",
+			resp.StatusCode, " reply: ", reply))
+	}
+	if rr.Type != RT_Confirmation && reply != "ok" {
+		return reply, errors.New(fmt.Sprint("vk: callback handler reply: ", reply))
+	}
+	return reply, nil
+}
@@ -28,7 +28,8 @@ func (t *EpochTime) UnmarshalJSON(s []byte) error {
 	return err
 }
 
-// Bool is a bool variable parsed from int
+// Bool is a bool variable parsed from int, or from JSON bool which newer API
+// versions send for some fields.
 type Bool bool
 
 func (b Bool) MarshalJSON() ([]byte, error) {
@@ -44,6 +45,14 @@ func (b *Bool) UnmarshalJSON(s []byte) error {
 		err error
 		q   int64
 	)
+	switch string(s) {
+	case "true":
+		*b = true
+		return nil
+	case "false":
+		*b = false
+		return nil
+	}
 	if q, err = strconv.ParseInt(string(s), 10, 32); err != nil {
 		return err
 	}
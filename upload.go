@@ -3,6 +3,7 @@ package vk
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -60,6 +61,10 @@ type (
 	UploadDocs struct {
 		File string `json:"file"`
 	}
+
+	UploadChatPhotos struct {
+		Response string `json:"response"`
+	}
 )
 
 func (u *UploadAlbumPhotos) parseType() interface{} {
@@ -82,6 +87,10 @@ func (u *UploadDocs) parseType() interface{} {
 	return u
 }
 
+func (u *UploadChatPhotos) parseType() interface{} {
+	return u
+}
+
 func (u *UploadAlbumPhotos) useStream() bool {
 	return true
 }
@@ -102,6 +111,10 @@ func (u *UploadDocs) useStream() bool {
 	return true
 }
 
+func (u *UploadChatPhotos) useStream() bool {
+	return true
+}
+
 type uploader interface {
 	uploadUrl(*Session) (string, error)
 	field(int) string
@@ -303,6 +316,45 @@ func getPMPhotoUploader(gid int) uploader {
 	}
 }
 
+// Chat photo
+const (
+	chatPhotoUploadServer = "photos.getChatUploadServer"
+	chatPhotoSave         = "messages.setChatPhoto"
+)
+
+type chatPhotoUpload struct {
+	*baseUpload
+	*UploadChatPhotos
+}
+
+func (u *chatPhotoUpload) postParse(s *Session, ns []string) (json.RawMessage, error) {
+	var res json.RawMessage
+	v := url.Values{}
+	v.Set("file", u.Response)
+	if err := s.CallAPI(chatPhotoSave, v, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (u *chatPhotoUpload) format(r json.RawMessage) ([]string, error) {
+	return nil, nil
+}
+
+func newChatPhotoUploader(chatId int) uploader {
+	v := url.Values{}
+	v.Set("chat_id", strconv.Itoa(chatId))
+	return &chatPhotoUpload{
+		baseUpload: &baseUpload{
+			mUp:   chatPhotoUploadServer,
+			f:     fileFieldName,
+			v:     v,
+			limit: 1,
+		},
+		UploadChatPhotos: &UploadChatPhotos{},
+	}
+}
+
 // Audio
 type audioUpload struct {
 	*baseUpload
@@ -429,6 +481,69 @@ func newCommunityDocUploader(gid int) uploader {
 	return getDocUploader(docUploadServer, gid)
 }
 
+// Message docs (voice message and graffiti)
+const (
+	msgDocUploadServer = "docs.getMessagesUploadServer"
+	msgDocAudio        = "audio_message"
+	msgDocGraffiti     = "graffiti"
+)
+
+type msgDocUpload struct {
+	*docUpload
+}
+
+// format supports both docs.save result format: array of doc before API 5.77
+// and object keyed by the doc type later.
+func (u *msgDocUpload) format(r json.RawMessage) ([]string, error) {
+	var docs []Doc
+	if err := unmarshalToType(r, &docs); err == nil {
+		return u.docUpload.format(r)
+	}
+	var v struct {
+		Type string    `json:"type"`
+		AM   *AudioMsg `json:"audio_message"`
+		Gr   *Graffiti `json:"graffiti"`
+		D    *Doc      `json:"doc"`
+	}
+	if err := unmarshalToType(r, &v); err != nil {
+		return nil, err
+	}
+	var owner, id int
+	var key string
+	switch {
+	case v.AM != nil:
+		owner, id, key = v.AM.OwnerId, v.AM.Id, v.AM.AccessKey
+	case v.Gr != nil:
+		owner, id, key = v.Gr.OwnerId, v.Gr.Id, v.Gr.AccessKey
+	case v.D != nil:
+		owner, id, key = v.D.OwnerId, v.D.Id, v.D.AccessKey
+	default:
+		return nil, errors.New(fmt.Sprint("vk: unknown saved doc type: ", v.Type))
+	}
+	s := fmt.Sprint("doc", strconv.Itoa(owner), "_", strconv.Itoa(id))
+	if key != "" {
+		s = fmt.Sprint(s, "_", key)
+	}
+	return []string{s}, nil
+}
+
+func newMsgDocUploader(typ string, peerId int) uploader {
+	v := url.Values{}
+	v.Set("type", typ)
+	v.Set("peer_id", strconv.Itoa(peerId))
+	return &msgDocUpload{
+		docUpload: &docUpload{
+			baseUpload: &baseUpload{
+				mUp:   msgDocUploadServer,
+				f:     fileFieldName,
+				v:     v,
+				limit: VK_MAX_DOCS,
+			},
+			UploadDocs: &UploadDocs{},
+		},
+	}
+}
+
 // Videos
 type videoUpload struct {
 	*baseUpload
@@ -598,6 +713,64 @@ func (s *Session) upload(ps, ns []string, up uploader) (json.RawMessage, error)
 	return up.postParse(s, ns)
 }
 
+// uploadReader uploads single file content r named n.
+func (s *Session) uploadReader(r io.Reader, n string, up uploader) (json.RawMessage, error) {
+	uurl, err := up.uploadUrl(s)
+	if err != nil {
+		return nil, err
+	}
+	pr, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
+	go func() {
+		part, err := writer.CreateFormFile(up.field(1), n)
+		if err == nil {
+			if _, err = io.Copy(part, r); err == nil {
+				err = writer.Close()
+			}
+		}
+		pw.CloseWithError(err)
+	}()
+	request, err := http.NewRequest("POST", uurl, pr)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Add("Content-Type", writer.FormDataContentType())
+	resp, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err = json.NewDecoder(resp.Body).Decode(up.parseType()); err != nil {
+		return nil, err
+	}
+	return up.postParse(s, []string{n})
+}
+
+func (s *Session) uploadMsgDoc(r io.Reader, n, typ string, peerId int) (string, error) {
+	up := newMsgDocUploader(typ, peerId)
+	res, err := s.uploadReader(r, n, up)
+	if err != nil {
+		return "", err
+	}
+	as, err := up.format(res)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(as, ","), nil
+}
+
+// UploadVoiceMessage uploads ogg (opus) voice message from r for conversation
+// peerId and returns the attachment string for MsgSendParams.Attachment.
+func (s *Session) UploadVoiceMessage(r io.Reader, peerId int) (string, error) {
+	return s.uploadMsgDoc(r, "voice.ogg", msgDocAudio, peerId)
+}
+
+// UploadGraffiti uploads png graffiti from r for conversation peerId and
+// returns the attachment string for MsgSendParams.Attachment.
+func (s *Session) UploadGraffiti(r io.Reader, peerId int) (string, error) {
+	return s.uploadMsgDoc(r, "graffiti.png", msgDocGraffiti, peerId)
+}
+
 // UploadPhotosToAlbum upload photos to album - either community album or
 // user album. ss are the path to the photo path. gid is the community id
 // if community album is the destination and aid is the album id.
@@ -613,6 +786,12 @@ func (s *Session) UploadPhotosToPM(ss []string, gid int) (json.RawMessage, error
 	return s.upload(ss, nil, getPMPhotoUploader(gid))
 }
 
+// UploadChatPhoto uploads the photo at path and sets it as the photo of
+// chat chatId. It returns the result of messages.setChatPhoto.
+func (s *Session) UploadChatPhoto(path string, chatId int) (json.RawMessage, error) {
+	return s.upload([]string{path}, nil, newChatPhotoUploader(chatId))
+}
+
 func (s *Session) UploadVideos(path string, v url.Values) error {
 	_, err := s.upload([]string{path}, nil, newVideoUploader(v, 0))
 	return err
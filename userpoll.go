@@ -0,0 +1,435 @@
+package vk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	// user long poll event codes (https://vk.com/dev/using_longpoll)
+	LPE_FlagsReplace = 1
+	LPE_FlagsSet     = 2
+	LPE_FlagsReset   = 3
+	LPE_NewMsg       = 4
+	LPE_ReadIn       = 6
+	LPE_ReadOut      = 7
+	LPE_Online       = 8
+	LPE_Offline      = 9
+	LPE_ChatParams   = 51
+	LPE_ChatInfo     = 52
+	LPE_Typing       = 61
+	LPE_ChatTyping   = 62
+
+	userLPVersion = 3
+	// attachments (2) + pts (32) + online extra (64) + random_id (128)
+	userLPMode = 226
+)
+
+var errLPVersion = errors.New("vk: user long poll version is not supported")
+
+type (
+	// UserLongPollServer is returned by messages.getLongPollServer
+	UserLongPollServer struct {
+		Key    string `json:"key"`
+		Server string `json:"server"`
+		Ts     LPTs   `json:"ts"`
+		Pts    int    `json:"pts"`
+	}
+
+	// UserLPEvent is a decoded user long poll update. Only the fields related
+	// to Code are set, see comment of each field.
+	UserLPEvent struct {
+		Code int
+		// LPE_FlagsReplace, LPE_FlagsSet, LPE_FlagsReset and LPE_NewMsg
+		MsgId int
+		// message flags (or mask) for LPE_Flags*, LPE_NewMsg and LPE_Typing.
+		// Offline reason for LPE_Offline (0 left, 1 timeout).
+		Flags int
+		// LPE_NewMsg, LPE_ReadIn, LPE_ReadOut and LPE_ChatInfo
+		PeerId int
+		// last read message ID for LPE_ReadIn and LPE_ReadOut
+		LocalId int
+		// LPE_Online, LPE_Offline, LPE_Typing and LPE_ChatTyping
+		UserId int
+		// LPE_ChatParams and LPE_ChatTyping
+		ChatId int
+		// LPE_ChatParams is caused by current user
+		Self Bool
+		// platform for LPE_Online
+		Platform int
+		// LPE_NewMsg, LPE_Online and LPE_Offline
+		Timestamp int64
+		// LPE_NewMsg
+		Text     string
+		Title    string
+		FromId   int
+		RandomId int
+		// raw attachments object of LPE_NewMsg
+		Attachments json.RawMessage
+		// LPE_ChatInfo
+		InfoType int
+		Info     int
+		// Message is the full message of LPE_NewMsg when FetchMessages of
+		// UserLongPoll is set.
+		Message *Message
+		Raw     json.RawMessage
+	}
+
+	userLPResponse struct {
+		Ts      LPTs              `json:"ts"`
+		Pts     int               `json:"pts"`
+		Updates []json.RawMessage `json:"updates"`
+		Failed  int               `json:"failed"`
+	}
+
+	// LPHistory is returned by messages.getLongPollHistory
+	LPHistory struct {
+		History  []json.RawMessage `json:"history"`
+		Messages Messages          `json:"messages"`
+		Profiles []User            `json:"profiles"`
+		NewPts   int               `json:"new_pts"`
+		More     Bool              `json:"more"`
+	}
+
+	// UserLongPoll receives personal message events of user token through
+	// user long poll.
+	UserLongPoll struct {
+		sess   *Session
+		server *UserLongPollServer
+		// Wait is the long poll waiting time in seconds.
+		Wait int
+		// FetchMessages makes LPE_NewMsg event carry full Message fetched by
+		// messages.getById.
+		FetchMessages bool
+		Client        *http.Client
+	}
+)
+
+// MsgsGetLongPollServer implements method
+// https://vk.com/dev/messages.getLongPollServer
+func (s *Session) MsgsGetLongPollServer() (*UserLongPollServer, error) {
+	v := url.Values{}
+	v.Set("need_pts", "1")
+	v.Set("lp_version", strconv.Itoa(userLPVersion))
+	var r UserLongPollServer
+	if err := s.CallAPI("messages.getLongPollServer", v, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// MsgsGetLongPollHistory implements method
+// https://vk.com/dev/messages.getLongPollHistory
+func (s *Session) MsgsGetLongPollHistory(ts string, pts int) (*LPHistory, error) {
+	v := url.Values{}
+	v.Set("ts", ts)
+	v.Set("pts", strconv.Itoa(pts))
+	v.Set("lp_version", strconv.Itoa(userLPVersion))
+	v.Set("onlines", "1")
+	var h LPHistory
+	if err := s.CallAPI("messages.getLongPollHistory", v, &h); err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+func lpInt(a []json.RawMessage, i int) int {
+	if i >= len(a) {
+		return 0
+	}
+	var n int
+	json.Unmarshal(a[i], &n)
+	return n
+}
+
+// DecodeUserLPEvent decodes one array encoded user long poll update.
+func DecodeUserLPEvent(b json.RawMessage) (*UserLPEvent, error) {
+	var a []json.RawMessage
+	if err := json.Unmarshal(b, &a); err != nil {
+		return nil, err
+	}
+	if len(a) == 0 {
+		return nil, errors.New("vk: empty long poll update")
+	}
+	e := &UserLPEvent{Code: lpInt(a, 0), Raw: b}
+	switch e.Code {
+	case LPE_FlagsReplace, LPE_FlagsSet, LPE_FlagsReset:
+		e.MsgId = lpInt(a, 1)
+		e.Flags = lpInt(a, 2)
+		e.PeerId = lpInt(a, 3)
+	case LPE_NewMsg:
+		e.MsgId = lpInt(a, 1)
+		e.Flags = lpInt(a, 2)
+		e.PeerId = lpInt(a, 3)
+		e.Timestamp = int64(lpInt(a, 4))
+		if len(a) > 5 {
+			json.Unmarshal(a[5], &e.Text)
+		}
+		if len(a) > 6 {
+			var extra struct {
+				Title string `json:"title"`
+				From  string `json:"from"`
+			}
+			json.Unmarshal(a[6], &extra)
+			e.Title = extra.Title
+			e.FromId, _ = strconv.Atoi(extra.From)
+		}
+		if len(a) > 7 {
+			e.Attachments = a[7]
+		}
+		e.RandomId = lpInt(a, 8)
+		if e.FromId == 0 {
+			e.FromId = e.PeerId
+		}
+	case LPE_ReadIn, LPE_ReadOut:
+		e.PeerId = lpInt(a, 1)
+		e.LocalId = lpInt(a, 2)
+	case LPE_Online, LPE_Offline:
+		e.UserId = -lpInt(a, 1)
+		if e.Code == LPE_Online {
+			e.Platform = lpInt(a, 2) & 0xff
+		} else {
+			e.Flags = lpInt(a, 2)
+		}
+		e.Timestamp = int64(lpInt(a, 3))
+	case LPE_ChatParams:
+		e.ChatId = lpInt(a, 1)
+		e.Self = Bool(lpInt(a, 2) == 1)
+	case LPE_ChatInfo:
+		e.InfoType = lpInt(a, 1)
+		e.PeerId = lpInt(a, 2)
+		e.Info = lpInt(a, 3)
+	case LPE_Typing:
+		e.UserId = lpInt(a, 1)
+		e.Flags = lpInt(a, 2)
+	case LPE_ChatTyping:
+		e.UserId = lpInt(a, 1)
+		e.ChatId = lpInt(a, 2)
+	}
+	return e, nil
+}
+
+// NewUserLongPoll creates user long poll client. s must be user token with
+// messages scope.
+func NewUserLongPoll(s *Session) *UserLongPoll {
+	return &UserLongPoll{
+		sess:   s,
+		Wait:   defaultLPWait,
+		Client: http.DefaultClient,
+	}
+}
+
+func (lp *UserLongPoll) refresh(keepTs bool) error {
+	srv, err := lp.sess.MsgsGetLongPollServer()
+	if err != nil {
+		return err
+	}
+	if keepTs && lp.server != nil {
+		srv.Ts = lp.server.Ts
+		srv.Pts = lp.server.Pts
+	}
+	lp.server = srv
+	return nil
+}
+
+// recoverHistory gets the events lost since ts and pts through
+// messages.getLongPollHistory. It pages till VK has no more history and
+// returns the pts following the recovered events.
+func (lp *UserLongPoll) recoverHistory(ts LPTs, pts int) ([]*UserLPEvent, int, error) {
+	var es []*UserLPEvent
+	for {
+		h, err := lp.sess.MsgsGetLongPollHistory(ts.String(), pts)
+		if err != nil {
+			return nil, 0, err
+		}
+		msgs := make(map[int]*Message, len(h.Messages.Items))
+		for _, m := range h.Messages.Items {
+			msgs[m.Id] = m
+		}
+		for _, b := range h.History {
+			e, err := DecodeUserLPEvent(b)
+			if err != nil {
+				return nil, 0, err
+			}
+			if e.Code == LPE_NewMsg && lp.FetchMessages {
+				e.Message = msgs[e.MsgId]
+			}
+			es = append(es, e)
+		}
+		if h.NewPts == 0 || h.NewPts == pts {
+			break
+		}
+		pts = h.NewPts
+		if !h.More {
+			break
+		}
+	}
+	if lp.FetchMessages {
+		// messages missing from the history
+		if err := lp.fetch(es); err != nil {
+			return nil, 0, err
+		}
+	}
+	return es, pts, nil
+}
+
+func (lp *UserLongPoll) fetch(es []*UserLPEvent) error {
+	var ids []int
+	for _, e := range es {
+		if e.Code == LPE_NewMsg && e.Message == nil {
+			ids = append(ids, e.MsgId)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	ms, err := lp.sess.MsgsGetById(ids, 0, false, nil)
+	if err != nil {
+		return err
+	}
+	msgs := make(map[int]*Message, len(ms.Items))
+	for _, m := range ms.Items {
+		msgs[m.Id] = m
+	}
+	for _, e := range es {
+		if e.Code == LPE_NewMsg && e.Message == nil {
+			e.Message = msgs[e.MsgId]
+		}
+	}
+	return nil
+}
+
+// Poll does one long poll request and returns decoded events. Events lost
+// because of expired ts or connection info are recovered through
+// messages.getLongPollHistory. On any error the old ts and pts are kept, so
+// failed recovery or failed fetching of FetchMessages is tried again by the
+// next Poll.
+func (lp *UserLongPoll) Poll(ctx context.Context) ([]*UserLPEvent, error) {
+	es, next, err := lp.poll(ctx)
+	if next != nil {
+		lp.server = next
+	}
+	return es, err
+}
+
+// poll is Poll without advancing ts and pts. It returns the connection info
+// to continue with after the events, or nil if it is unchanged.
+func (lp *UserLongPoll) poll(ctx context.Context) ([]*UserLPEvent, *UserLongPollServer, error) {
+	if lp.server == nil {
+		if err := lp.refresh(false); err != nil {
+			return nil, nil, err
+		}
+	}
+	q := url.Values{}
+	q.Set("act", "a_check")
+	q.Set("key", lp.server.Key)
+	q.Set("ts", lp.server.Ts.String())
+	q.Set("wait", strconv.Itoa(lp.Wait))
+	q.Set("mode", strconv.Itoa(userLPMode))
+	q.Set("version", strconv.Itoa(userLPVersion))
+	req, err := http.NewRequest("GET", fmt.Sprint("https://", lp.server.Server, "?", q.Encode()), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := lp.Client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	var r userLPResponse
+	if err = json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return nil, nil, err
+	}
+	next := *lp.server
+	switch r.Failed {
+	case 0:
+	case LPFailedHistory:
+		es, pts, err := lp.recoverHistory(lp.server.Ts, lp.server.Pts)
+		if err != nil {
+			return nil, nil, err
+		}
+		next.Ts = r.Ts
+		next.Pts = pts
+		return es, &next, nil
+	case LPFailedKey:
+		return nil, nil, lp.refresh(true)
+	case LPFailedInfo:
+		srv, err := lp.sess.MsgsGetLongPollServer()
+		if err != nil {
+			return nil, nil, err
+		}
+		es, pts, err := lp.recoverHistory(lp.server.Ts, lp.server.Pts)
+		if err != nil {
+			return nil, nil, err
+		}
+		if pts > srv.Pts {
+			srv.Pts = pts
+		}
+		return es, srv, nil
+	case LPFailedVersion:
+		return nil, nil, errLPVersion
+	default:
+		return nil, nil, errors.New(fmt.Sprint("vk: user long poll failed: ", r.Failed))
+	}
+	next.Ts = r.Ts
+	if r.Pts != 0 {
+		next.Pts = r.Pts
+	}
+	es := make([]*UserLPEvent, 0, len(r.Updates))
+	for _, b := range r.Updates {
+		e, err := DecodeUserLPEvent(b)
+		if err != nil {
+			return nil, nil, err
+		}
+		es = append(es, e)
+	}
+	if lp.FetchMessages {
+		// ts is kept so the batch and its messages are fetched again
+		if err = lp.fetch(es); err != nil {
+			return nil, nil, err
+		}
+	}
+	return es, &next, nil
+}
+
+// Run polls till ctx is done and passes every event to h. Error from h or
+// unsupported long poll version stops Run and is returned. Ts is only
+// advanced after h handled the whole batch, so the batch is received again
+// by the next Poll or Run. Failed request is retried after short delay with
+// the same ts, so h never gets LPE_NewMsg without Message when FetchMessages
+// is set.
+func (lp *UserLongPoll) Run(ctx context.Context, h func(*UserLPEvent) error) error {
+	for {
+		es, next, err := lp.poll(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err == errLPVersion {
+			return err
+		} else if err != nil {
+			if Debug {
+				fmt.Printf("vk user long poll: %v\n", err)
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(lpRetryDelay):
+			}
+			continue
+		}
+		for _, e := range es {
+			if err = h(e); err != nil {
+				return err
+			}
+		}
+		if next != nil {
+			lp.server = next
+		}
+	}
+}